@@ -0,0 +1,128 @@
+package cc_messages
+
+import "errors"
+
+var (
+	ErrDeploymentStrategyNegative = errors.New("Invalid deployment strategy: values must not be negative")
+	ErrDeploymentStrategyStalled  = errors.New("Invalid deployment strategy: max surge and max unavailable cannot both be zero")
+)
+
+// DeploymentStrategy describes a rolling update. MaxSurge is how many
+// instances may run above the desired count, MaxUnavailable how many may be
+// missing below it. Up to CanaryInstances new instances are started first,
+// within those bounds, and the rollout pauses after them.
+type DeploymentStrategy struct {
+	MaxSurge        int `json:"max_surge"`
+	MaxUnavailable  int `json:"max_unavailable"`
+	CanaryInstances int `json:"canary_instances,omitempty"`
+}
+
+type DeploymentPlan struct {
+	FromETag string
+	ToETag   string
+	Steps    []DeploymentStep
+}
+
+// DeploymentStep starts and stops instances, leaving FromInstances of the old
+// version and ToInstances of the new version running. A Canary step is
+// followed by a pause to verify the new version.
+type DeploymentStep struct {
+	StartInstances int
+	StopInstances  int
+	FromInstances  int
+	ToInstances    int
+	Canary         bool
+}
+
+func (s DeploymentStrategy) Validate() error {
+	if s.MaxSurge < 0 || s.MaxUnavailable < 0 || s.CanaryInstances < 0 {
+		return ErrDeploymentStrategyNegative
+	}
+	if s.MaxSurge == 0 && s.MaxUnavailable == 0 {
+		return ErrDeploymentStrategyStalled
+	}
+	return nil
+}
+
+// Plan computes the steps that replace fromInstances instances at fromETag
+// with toInstances instances at toETag. Equal etags need no transition.
+func (s DeploymentStrategy) Plan(fromETag string, fromInstances int, toETag string, toInstances int) (DeploymentPlan, error) {
+	plan := DeploymentPlan{FromETag: fromETag, ToETag: toETag, Steps: []DeploymentStep{}}
+	if fromETag == toETag {
+		return plan, nil
+	}
+
+	err := s.Validate()
+	if err != nil {
+		return DeploymentPlan{}, err
+	}
+
+	from, to := fromInstances, 0
+	maxTotal := toInstances + s.MaxSurge
+	minAvailable := toInstances - s.MaxUnavailable
+
+	if s.CanaryInstances > 0 && toInstances > 0 {
+		// the canaries may be fewer than asked for when surge and
+		// availability leave no room for all of them
+		maxStop := minInt(from, maxInt(0, from-minAvailable))
+		start := minInt(minInt(s.CanaryInstances, toInstances), maxTotal-(from-maxStop))
+		stop := minInt(maxStop, maxInt(0, from+start-maxTotal))
+		from, to = from-stop, to+start
+		plan.Steps = append(plan.Steps, DeploymentStep{
+			StartInstances: start,
+			StopInstances:  stop,
+			FromInstances:  from,
+			ToInstances:    to,
+			Canary:         true,
+		})
+	}
+
+	for from > 0 || to < toInstances {
+		// instances started in this step are not yet available, so only
+		// the ones already running count towards minAvailable
+		stop := minInt(from, maxInt(0, from+to-minAvailable))
+		start := minInt(toInstances-to, maxInt(0, maxTotal-(from-stop+to)))
+
+		from, to = from-stop, to+start
+		plan.Steps = append(plan.Steps, DeploymentStep{
+			StartInstances: start,
+			StopInstances:  stop,
+			FromInstances:  from,
+			ToInstances:    to,
+		})
+	}
+
+	return plan, nil
+}
+
+// PlanDeployment plans the transition from the currently desired app to this
+// one. Without a deployment strategy every instance is recreated at once.
+func (r DesireAppRequestFromCC) PlanDeployment(current DesireAppRequestFromCC) (DeploymentPlan, error) {
+	if r.DeploymentStrategy == nil {
+		plan := DeploymentPlan{FromETag: current.ETag, ToETag: r.ETag, Steps: []DeploymentStep{}}
+		if current.ETag != r.ETag {
+			plan.Steps = append(plan.Steps, DeploymentStep{
+				StartInstances: r.NumInstances,
+				StopInstances:  current.NumInstances,
+				ToInstances:    r.NumInstances,
+			})
+		}
+		return plan, nil
+	}
+
+	return r.DeploymentStrategy.Plan(current.ETag, current.NumInstances, r.ETag, r.NumInstances)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,154 @@
+package cc_messages_test
+
+import (
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeploymentStrategy", func() {
+	Describe("Validate", func() {
+		It("rejects strategies that cannot make progress", func() {
+			Expect(cc_messages.DeploymentStrategy{}.Validate()).To(Equal(cc_messages.ErrDeploymentStrategyStalled))
+		})
+
+		It("rejects negative values", func() {
+			Expect(cc_messages.DeploymentStrategy{MaxSurge: -1, MaxUnavailable: 1}.Validate()).To(Equal(cc_messages.ErrDeploymentStrategyNegative))
+		})
+	})
+
+	Describe("Plan", func() {
+		It("surges one instance at a time without losing availability", func() {
+			strategy := cc_messages.DeploymentStrategy{MaxSurge: 1}
+
+			plan, err := strategy.Plan("etag-1", 3, "etag-2", 3)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(Equal(cc_messages.DeploymentPlan{
+				FromETag: "etag-1",
+				ToETag:   "etag-2",
+				Steps: []cc_messages.DeploymentStep{
+					{StartInstances: 1, StopInstances: 0, FromInstances: 3, ToInstances: 1},
+					{StartInstances: 1, StopInstances: 1, FromInstances: 2, ToInstances: 2},
+					{StartInstances: 1, StopInstances: 1, FromInstances: 1, ToInstances: 3},
+					{StartInstances: 0, StopInstances: 1, FromInstances: 0, ToInstances: 3},
+				},
+			}))
+		})
+
+		It("replaces instances in place when surging is not allowed", func() {
+			strategy := cc_messages.DeploymentStrategy{MaxUnavailable: 1}
+
+			plan, err := strategy.Plan("etag-1", 2, "etag-2", 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Steps).To(Equal([]cc_messages.DeploymentStep{
+				{StartInstances: 1, StopInstances: 1, FromInstances: 1, ToInstances: 1},
+				{StartInstances: 1, StopInstances: 1, FromInstances: 0, ToInstances: 2},
+			}))
+		})
+
+		It("starts the canaries first", func() {
+			strategy := cc_messages.DeploymentStrategy{MaxSurge: 2, MaxUnavailable: 1, CanaryInstances: 1}
+
+			plan, err := strategy.Plan("etag-1", 4, "etag-2", 4)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Steps[0]).To(Equal(cc_messages.DeploymentStep{
+				StartInstances: 1, FromInstances: 4, ToInstances: 1, Canary: true,
+			}))
+
+			last := plan.Steps[len(plan.Steps)-1]
+			Expect(last.FromInstances).To(Equal(0))
+			Expect(last.ToInstances).To(Equal(4))
+
+			for _, step := range plan.Steps {
+				Expect(step.FromInstances + step.ToInstances).To(BeNumerically("<=", 6))
+			}
+		})
+
+		It("keeps the canary step within the availability budget", func() {
+			strategy := cc_messages.DeploymentStrategy{MaxUnavailable: 1, CanaryInstances: 3}
+
+			plan, err := strategy.Plan("a", 5, "b", 5)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Steps[0]).To(Equal(cc_messages.DeploymentStep{
+				StartInstances: 1, StopInstances: 1, FromInstances: 4, ToInstances: 1, Canary: true,
+			}))
+		})
+
+		It("never drops below the available or above the surge bound", func() {
+			strategies := []cc_messages.DeploymentStrategy{
+				{MaxSurge: 1},
+				{MaxUnavailable: 1},
+				{MaxSurge: 2, MaxUnavailable: 1},
+				{MaxUnavailable: 1, CanaryInstances: 3},
+				{MaxSurge: 1, CanaryInstances: 2},
+				{MaxSurge: 1, MaxUnavailable: 2, CanaryInstances: 5},
+				{MaxUnavailable: 2, CanaryInstances: 1},
+			}
+
+			for _, strategy := range strategies {
+				for _, instances := range []int{1, 2, 5, 10} {
+					plan, err := strategy.Plan("a", instances, "b", instances)
+					Expect(err).NotTo(HaveOccurred())
+
+					minAvailable := instances - strategy.MaxUnavailable
+					maxTotal := instances + strategy.MaxSurge
+					previousTo := 0
+					for i, step := range plan.Steps {
+						// instances started in this step are not yet available
+						Expect(step.FromInstances+previousTo).To(BeNumerically(">=", minAvailable), "%+v %d step %d", strategy, instances, i)
+						Expect(step.FromInstances+step.ToInstances).To(BeNumerically("<=", maxTotal), "%+v %d step %d", strategy, instances, i)
+						previousTo = step.ToInstances
+					}
+
+					last := plan.Steps[len(plan.Steps)-1]
+					Expect(last.FromInstances).To(Equal(0))
+					Expect(last.ToInstances).To(Equal(instances))
+				}
+			}
+		})
+
+		It("handles scaling during the rollout", func() {
+			strategy := cc_messages.DeploymentStrategy{MaxSurge: 1, MaxUnavailable: 1}
+
+			plan, err := strategy.Plan("etag-1", 1, "etag-2", 3)
+			Expect(err).NotTo(HaveOccurred())
+
+			last := plan.Steps[len(plan.Steps)-1]
+			Expect(last.FromInstances).To(Equal(0))
+			Expect(last.ToInstances).To(Equal(3))
+		})
+
+		It("needs no steps when the etag has not changed", func() {
+			plan, err := cc_messages.DeploymentStrategy{MaxSurge: 1}.Plan("etag-1", 3, "etag-1", 3)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Steps).To(BeEmpty())
+		})
+	})
+
+	Describe("PlanDeployment", func() {
+		current := cc_messages.DesireAppRequestFromCC{ETag: "etag-1", NumInstances: 2}
+
+		It("recreates every instance without a strategy", func() {
+			desired := cc_messages.DesireAppRequestFromCC{ETag: "etag-2", NumInstances: 3}
+
+			plan, err := desired.PlanDeployment(current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Steps).To(Equal([]cc_messages.DeploymentStep{
+				{StartInstances: 3, StopInstances: 2, FromInstances: 0, ToInstances: 3},
+			}))
+		})
+
+		It("uses the desired app's strategy", func() {
+			desired := cc_messages.DesireAppRequestFromCC{
+				ETag:               "etag-2",
+				NumInstances:       2,
+				DeploymentStrategy: &cc_messages.DeploymentStrategy{MaxUnavailable: 1},
+			}
+
+			plan, err := desired.PlanDeployment(current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Steps).To(HaveLen(2))
+		})
+	})
+})
@@ -24,15 +24,16 @@ const (
 )
 
 type DesireAppRequestFromCC struct {
+	DockerCredentials
+	PlacementConstraints
+
 	ProcessGuid                 string                        `json:"process_guid"`
 	DropletUri                  string                        `json:"droplet_uri"`
 	DockerImageUrl              string                        `json:"docker_image"`
-	DockerLoginServer           string                        `json:"docker_login_server,omitempty"`
-	DockerUser                  string                        `json:"docker_user,omitempty"`
-	DockerPassword              string                        `json:"docker_password,omitempty"`
-	DockerEmail                 string                        `json:"docker_email,omitempty"`
 	Stack                       string                        `json:"stack"`
 	StartCommand                string                        `json:"start_command"`
+	ProcessType                 string                        `json:"process_type,omitempty"`
+	ProcessTypes                ProcessTypes                  `json:"process_types,omitempty"`
 	ExecutionMetadata           string                        `json:"execution_metadata"`
 	Environment                 []*models.EnvironmentVariable `json:"environment"`
 	MemoryMB                    int                           `json:"memory_mb"`
@@ -50,6 +51,8 @@ type DesireAppRequestFromCC struct {
 	LogSource                   string                        `json:"log_source,omitempty"`
 	Network                     *models.Network               `json:"network,omitempty"`
 	VolumeMounts                []*models.VolumeMount         `json:"volume_mounts"`
+	Sidecars                    []Sidecar                     `json:"sidecars,omitempty"`
+	DeploymentStrategy          *DeploymentStrategy           `json:"deployment_strategy,omitempty"`
 }
 
 type CCRouteInfo map[string]*json.RawMessage
@@ -57,6 +60,8 @@ type CCRouteInfo map[string]*json.RawMessage
 type CCHTTPRoutes []CCHTTPRoute
 
 func (r CCHTTPRoutes) CCRouteInfo() (CCRouteInfo, error) {
+	defer timeConversion(HTTPRoutesConversion)()
+
 	routesJson, err := json.Marshal(r)
 	if err != nil {
 		return nil, err
@@ -83,6 +88,8 @@ type CCTCPRoute struct {
 }
 
 func (r CCTCPRoutes) CCRouteInfo() (CCRouteInfo, error) {
+	defer timeConversion(TCPRoutesConversion)()
+
 	routesJson, err := json.Marshal(r)
 	if err != nil {
 		return nil, err
@@ -127,6 +134,8 @@ type CCBulkToken struct {
 type TaskErrorID string
 
 type TaskRequestFromCC struct {
+	PlacementConstraints
+
 	TaskGuid              string                        `json:"task_guid"`
 	LogGuid               string                        `json:"log_guid"`
 	MemoryMb              int                           `json:"memory_mb"`
@@ -150,6 +159,7 @@ type TaskFailResponseForCC struct {
 }
 
 type TaskError struct {
-	Id      TaskErrorID `json:"id"`
-	Message string      `json:"message"`
+	Id      TaskErrorID            `json:"id"`
+	Message string                 `json:"message"`
+	Details *PlacementErrorDetails `json:"details,omitempty"`
 }
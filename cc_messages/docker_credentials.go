@@ -0,0 +1,76 @@
+package cc_messages
+
+import "errors"
+
+type DockerAuthType string
+
+const (
+	DockerAuthNone             DockerAuthType = ""
+	DockerAuthBasic            DockerAuthType = "basic"
+	DockerAuthToken            DockerAuthType = "token"
+	DockerAuthCredentialHelper DockerAuthType = "credential_helper"
+	DockerAuthHeader           DockerAuthType = "auth_header"
+)
+
+var (
+	ErrDockerCredentialsConflict   = errors.New("more than one docker authentication method specified")
+	ErrDockerCredentialsIncomplete = errors.New("docker user and password must be specified together")
+)
+
+// DockerCredentials holds the registry credentials shared by the desire and
+// staging requests. It is embedded so that its fields keep their original
+// place in the JSON payloads.
+type DockerCredentials struct {
+	DockerLoginServer      string `json:"docker_login_server,omitempty"`
+	DockerUser             string `json:"docker_user,omitempty"`
+	DockerPassword         string `json:"docker_password,omitempty"`
+	DockerEmail            string `json:"docker_email,omitempty"`
+	DockerToken            string `json:"docker_token,omitempty"`
+	DockerCredentialHelper string `json:"docker_credential_helper,omitempty"`
+	DockerAuthHeader       string `json:"docker_auth_header,omitempty"`
+}
+
+func (c DockerCredentials) DockerAuthType() DockerAuthType {
+	switch {
+	case c.DockerToken != "":
+		return DockerAuthToken
+	case c.DockerCredentialHelper != "":
+		return DockerAuthCredentialHelper
+	case c.DockerAuthHeader != "":
+		return DockerAuthHeader
+	case c.DockerUser != "" || c.DockerPassword != "":
+		return DockerAuthBasic
+	}
+	return DockerAuthNone
+}
+
+func (c DockerCredentials) ValidateDockerCredentials() error {
+	methods := 0
+	if c.DockerUser != "" || c.DockerPassword != "" {
+		methods++
+		if c.DockerUser == "" || c.DockerPassword == "" {
+			return ErrDockerCredentialsIncomplete
+		}
+	}
+	for _, value := range []string{c.DockerToken, c.DockerCredentialHelper, c.DockerAuthHeader} {
+		if value != "" {
+			methods++
+		}
+	}
+
+	if methods > 1 {
+		return ErrDockerCredentialsConflict
+	}
+	return nil
+}
+
+// RedactedDockerCredentials masks every secret while keeping the login
+// server and credential helper, which are safe to log.
+func (c DockerCredentials) RedactedDockerCredentials() DockerCredentials {
+	c.DockerUser = redactString(c.DockerUser)
+	c.DockerPassword = redactString(c.DockerPassword)
+	c.DockerEmail = redactString(c.DockerEmail)
+	c.DockerToken = redactString(c.DockerToken)
+	c.DockerAuthHeader = redactString(c.DockerAuthHeader)
+	return c
+}
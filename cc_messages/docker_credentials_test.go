@@ -0,0 +1,92 @@
+package cc_messages_test
+
+import (
+	"encoding/json"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DockerCredentials", func() {
+	It("keeps the credentials at the top level of the desire app JSON", func() {
+		request := cc_messages.DesireAppRequestFromCC{
+			DockerCredentials: cc_messages.DockerCredentials{
+				DockerUser:     "user",
+				DockerPassword: "password",
+			},
+		}
+
+		payload, err := json.Marshal(request)
+		Expect(err).NotTo(HaveOccurred())
+
+		var fields map[string]interface{}
+		Expect(json.Unmarshal(payload, &fields)).To(Succeed())
+		Expect(fields).To(HaveKeyWithValue("docker_user", "user"))
+		Expect(fields).To(HaveKeyWithValue("docker_password", "password"))
+		Expect(fields).NotTo(HaveKey("docker_token"))
+	})
+
+	It("unmarshals the alternative authentication fields", func() {
+		var stagingData cc_messages.DockerStagingData
+		err := json.Unmarshal([]byte(`{
+			"docker_image": "docker:///diego/image",
+			"docker_login_server": "registry.example.com",
+			"docker_credential_helper": "ecr-login"
+		}`), &stagingData)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(stagingData).To(Equal(cc_messages.DockerStagingData{
+			DockerCredentials: cc_messages.DockerCredentials{
+				DockerLoginServer:      "registry.example.com",
+				DockerCredentialHelper: "ecr-login",
+			},
+			DockerImageUrl: "docker:///diego/image",
+		}))
+	})
+
+	Describe("DockerAuthType", func() {
+		It("reports the authentication method in use", func() {
+			Expect(cc_messages.DockerCredentials{}.DockerAuthType()).To(Equal(cc_messages.DockerAuthNone))
+			Expect(cc_messages.DockerCredentials{DockerUser: "u", DockerPassword: "p"}.DockerAuthType()).To(Equal(cc_messages.DockerAuthBasic))
+			Expect(cc_messages.DockerCredentials{DockerToken: "t"}.DockerAuthType()).To(Equal(cc_messages.DockerAuthToken))
+			Expect(cc_messages.DockerCredentials{DockerCredentialHelper: "h"}.DockerAuthType()).To(Equal(cc_messages.DockerAuthCredentialHelper))
+			Expect(cc_messages.DockerCredentials{DockerAuthHeader: "Bearer t"}.DockerAuthType()).To(Equal(cc_messages.DockerAuthHeader))
+		})
+	})
+
+	Describe("ValidateDockerCredentials", func() {
+		It("accepts a single authentication method", func() {
+			Expect(cc_messages.DockerCredentials{}.ValidateDockerCredentials()).To(Succeed())
+			Expect(cc_messages.DockerCredentials{DockerUser: "u", DockerPassword: "p", DockerEmail: "e"}.ValidateDockerCredentials()).To(Succeed())
+			Expect(cc_messages.DockerCredentials{DockerToken: "t"}.ValidateDockerCredentials()).To(Succeed())
+		})
+
+		It("errors when a user is given without a password", func() {
+			err := cc_messages.DockerCredentials{DockerUser: "u"}.ValidateDockerCredentials()
+			Expect(err).To(Equal(cc_messages.ErrDockerCredentialsIncomplete))
+		})
+
+		It("errors when more than one method is given", func() {
+			err := cc_messages.DockerCredentials{DockerUser: "u", DockerPassword: "p", DockerToken: "t"}.ValidateDockerCredentials()
+			Expect(err).To(Equal(cc_messages.ErrDockerCredentialsConflict))
+		})
+	})
+
+	Describe("RedactedDockerCredentials", func() {
+		It("masks the secrets and keeps the login server and helper", func() {
+			credentials := cc_messages.DockerCredentials{
+				DockerLoginServer:      "registry.example.com",
+				DockerToken:            "token",
+				DockerCredentialHelper: "ecr-login",
+			}
+
+			Expect(credentials.RedactedDockerCredentials()).To(Equal(cc_messages.DockerCredentials{
+				DockerLoginServer:      "registry.example.com",
+				DockerToken:            cc_messages.RedactedValue,
+				DockerCredentialHelper: "ecr-login",
+			}))
+		})
+	})
+})
@@ -0,0 +1,196 @@
+package cc_messages
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	DockerImageScheme     = "docker"
+	DefaultDockerRegistry = "docker.io"
+	DefaultDockerTag      = "latest"
+
+	dockerOfficialRepositoryPrefix = "library/"
+)
+
+var dockerDefaultRegistryAliases = []string{
+	"docker.io",
+	"index.docker.io",
+	"registry-1.docker.io",
+	"registry.hub.docker.com",
+}
+
+var (
+	ErrDockerImageEmpty             = errors.New("empty docker image")
+	ErrDockerImageSchemeInvalid     = errors.New("docker image scheme must be 'docker'")
+	ErrDockerImageRepositoryInvalid = errors.New("invalid docker image repository")
+	ErrDockerImageTagInvalid        = errors.New("invalid docker image tag")
+	ErrDockerImageDigestInvalid     = errors.New("invalid docker image digest")
+)
+
+var (
+	dockerRepositoryComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+	dockerTagRegexp                 = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	dockerDigestRegexp              = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-fA-F0-9]{32,}$`)
+)
+
+type DockerImage struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseDockerImageUrl splits a docker image URL, such as
+// docker:///diego/image or docker://registry:5000/diego/image#tag, into
+// its parts. An empty registry means the default registry.
+func ParseDockerImageUrl(imageUrl string) (DockerImage, error) {
+	if imageUrl == "" {
+		return DockerImage{}, ErrDockerImageEmpty
+	}
+
+	image := DockerImage{}
+	remainder := imageUrl
+
+	if i := strings.Index(remainder, "://"); i >= 0 {
+		if remainder[:i] != DockerImageScheme {
+			return DockerImage{}, ErrDockerImageSchemeInvalid
+		}
+
+		remainder = remainder[i+len("://"):]
+		slash := strings.Index(remainder, "/")
+		if slash < 0 {
+			return DockerImage{}, ErrDockerImageRepositoryInvalid
+		}
+		image.Registry = remainder[:slash]
+		remainder = remainder[slash+1:]
+	} else if slash := strings.Index(remainder, "/"); slash >= 0 && isDockerRegistryHost(remainder[:slash]) {
+		image.Registry = remainder[:slash]
+		remainder = remainder[slash+1:]
+	}
+
+	if i := strings.Index(remainder, "@"); i >= 0 {
+		image.Digest = remainder[i+1:]
+		remainder = remainder[:i]
+		if !dockerDigestRegexp.MatchString(image.Digest) {
+			return DockerImage{}, ErrDockerImageDigestInvalid
+		}
+	}
+
+	if i := strings.Index(remainder, "#"); i >= 0 {
+		image.Tag = remainder[i+1:]
+		remainder = remainder[:i]
+	} else if i := strings.LastIndex(remainder, ":"); i > strings.LastIndex(remainder, "/") {
+		image.Tag = remainder[i+1:]
+		remainder = remainder[:i]
+	}
+
+	if image.Tag != "" && !dockerTagRegexp.MatchString(image.Tag) {
+		return DockerImage{}, ErrDockerImageTagInvalid
+	}
+
+	if remainder == "" {
+		return DockerImage{}, ErrDockerImageRepositoryInvalid
+	}
+	for _, component := range strings.Split(remainder, "/") {
+		if !dockerRepositoryComponentRegexp.MatchString(component) {
+			return DockerImage{}, ErrDockerImageRepositoryInvalid
+		}
+	}
+	image.Repository = remainder
+
+	return image, nil
+}
+
+// ParseDockerImageReference parses any of the docker image forms used by CC
+// and returns the normalized image.
+func ParseDockerImageReference(reference string) (DockerImage, error) {
+	image, err := ParseDockerImageUrl(reference)
+	if err != nil {
+		return DockerImage{}, err
+	}
+	return image.Normalized(), nil
+}
+
+// Normalized fills in the default registry, the library/ prefix of
+// official images and the default tag, so that equivalent references compare
+// equal.
+func (i DockerImage) Normalized() DockerImage {
+	i.Registry = NormalizeDockerRegistryHost(i.Registry)
+	if i.Registry == DefaultDockerRegistry && !strings.Contains(i.Repository, "/") {
+		i.Repository = dockerOfficialRepositoryPrefix + i.Repository
+	}
+	if i.Tag == "" && i.Digest == "" {
+		i.Tag = DefaultDockerTag
+	}
+	return i
+}
+
+// String returns the canonical registry/repository[:tag][@digest] form of
+// the normalized image.
+func (i DockerImage) String() string {
+	normalized := i.Normalized()
+
+	reference := normalized.Registry + "/" + normalized.Repository
+	if normalized.Tag != "" {
+		reference += ":" + normalized.Tag
+	}
+	if normalized.Digest != "" {
+		reference += "@" + normalized.Digest
+	}
+	return reference
+}
+
+func (i DockerImage) RegistryHost() string {
+	return NormalizeDockerRegistryHost(i.Registry)
+}
+
+// MatchesLoginServer reports whether credentials for the given
+// DockerLoginServer apply to the image. An empty login server means the
+// default registry.
+func (i DockerImage) MatchesLoginServer(loginServer string) bool {
+	return i.RegistryHost() == NormalizeDockerRegistryHost(loginServer)
+}
+
+// NormalizeDockerRegistryHost reduces a registry or login server, such as
+// https://index.docker.io/v1/, to its host and maps the aliases of the
+// default registry to DefaultDockerRegistry.
+func NormalizeDockerRegistryHost(server string) string {
+	host := server
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+len("://"):]
+	}
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	host = strings.ToLower(host)
+
+	if host == "" {
+		return DefaultDockerRegistry
+	}
+	for _, alias := range dockerDefaultRegistryAliases {
+		if host == alias || host == alias+":443" {
+			return DefaultDockerRegistry
+		}
+	}
+	return host
+}
+
+// isDockerRegistryHost follows the docker convention that the first path
+// component names a registry only if it looks like a host.
+func isDockerRegistryHost(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
+
+func (r DesireAppRequestFromCC) DockerImage() (DockerImage, error) {
+	return ParseDockerImageUrl(r.DockerImageUrl)
+}
+
+func (d DockerStagingData) DockerImage() (DockerImage, error) {
+	return ParseDockerImageUrl(d.DockerImageUrl)
+}
+
+func (r TaskRequestFromCC) DockerImage() (DockerImage, error) {
+	return ParseDockerImageUrl(r.DockerPath)
+}
@@ -0,0 +1,99 @@
+package cc_messages_test
+
+import (
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DockerImage", func() {
+	Describe("ParseDockerImageUrl", func() {
+		DescribeTable("valid image urls",
+			func(imageUrl string, expected cc_messages.DockerImage) {
+				image, err := cc_messages.ParseDockerImageUrl(imageUrl)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(image).To(Equal(expected))
+			},
+			Entry("default registry", "docker:///diego/image",
+				cc_messages.DockerImage{Repository: "diego/image"}),
+			Entry("fragment tag", "docker:///diego/image#v1",
+				cc_messages.DockerImage{Repository: "diego/image", Tag: "v1"}),
+			Entry("registry with port and tag", "docker://registry.example.com:5000/diego/image:v1",
+				cc_messages.DockerImage{Registry: "registry.example.com:5000", Repository: "diego/image", Tag: "v1"}),
+			Entry("plain repository", "busybox",
+				cc_messages.DockerImage{Repository: "busybox"}),
+			Entry("plain registry", "localhost/diego/image",
+				cc_messages.DockerImage{Registry: "localhost", Repository: "diego/image"}),
+			Entry("digest", "diego/image@sha256:9d4e1c5b3e8a1a2c2d6f2d0f4b8d2a7e6c1b0a9f8e7d6c5b4a39281706f5e4d3",
+				cc_messages.DockerImage{Repository: "diego/image", Digest: "sha256:9d4e1c5b3e8a1a2c2d6f2d0f4b8d2a7e6c1b0a9f8e7d6c5b4a39281706f5e4d3"}),
+		)
+
+		DescribeTable("invalid image urls",
+			func(imageUrl string, expectedErr error) {
+				_, err := cc_messages.ParseDockerImageUrl(imageUrl)
+				Expect(err).To(Equal(expectedErr))
+			},
+			Entry("empty", "", cc_messages.ErrDockerImageEmpty),
+			Entry("wrong scheme", "http://registry/image", cc_messages.ErrDockerImageSchemeInvalid),
+			Entry("missing repository", "docker://registry", cc_messages.ErrDockerImageRepositoryInvalid),
+			Entry("upper case repository", "docker:///Diego/Image", cc_messages.ErrDockerImageRepositoryInvalid),
+			Entry("bad tag", "diego/image:-v1", cc_messages.ErrDockerImageTagInvalid),
+			Entry("bad digest", "diego/image@sha256:xyz", cc_messages.ErrDockerImageDigestInvalid),
+		)
+	})
+
+	It("parses the image of a desire app request", func() {
+		request := cc_messages.DesireAppRequestFromCC{DockerImageUrl: "docker:///diego/image"}
+		image, err := request.DockerImage()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image.Repository).To(Equal("diego/image"))
+	})
+})
+
+var _ = Describe("DockerImage normalization", func() {
+	DescribeTable("ParseDockerImageReference",
+		func(reference, canonical, registryHost string) {
+			image, err := cc_messages.ParseDockerImageReference(reference)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(image.String()).To(Equal(canonical))
+			Expect(image.RegistryHost()).To(Equal(registryHost))
+		},
+		Entry("default registry url", "docker:///diego/image", "docker.io/diego/image:latest", "docker.io"),
+		Entry("official image", "docker:///ubuntu#14.04", "docker.io/library/ubuntu:14.04", "docker.io"),
+		Entry("registry alias", "docker://index.docker.io/busybox", "docker.io/library/busybox:latest", "docker.io"),
+		Entry("private registry", "docker://registry.example.com:5000/diego/image:v1", "registry.example.com:5000/diego/image:v1", "registry.example.com:5000"),
+		Entry("private registry single component", "registry.example.com/image", "registry.example.com/image:latest", "registry.example.com"),
+		Entry("plain digest", "diego/image@sha256:9d4e1c5b3e8a1a2c2d6f2d0f4b8d2a7e6c1b0a9f8e7d6c5b4a39281706f5e4d3",
+			"docker.io/diego/image@sha256:9d4e1c5b3e8a1a2c2d6f2d0f4b8d2a7e6c1b0a9f8e7d6c5b4a39281706f5e4d3", "docker.io"),
+	)
+
+	It("treats equivalent references as equal", func() {
+		first, err := cc_messages.ParseDockerImageReference("docker:///ubuntu")
+		Expect(err).NotTo(HaveOccurred())
+		second, err := cc_messages.ParseDockerImageReference("docker.io/library/ubuntu:latest")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(first).To(Equal(second))
+	})
+
+	DescribeTable("MatchesLoginServer",
+		func(reference, loginServer string, matches bool) {
+			image, err := cc_messages.ParseDockerImageUrl(reference)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(image.MatchesLoginServer(loginServer)).To(Equal(matches))
+		},
+		Entry("default registry and empty login server", "docker:///diego/image", "", true),
+		Entry("default registry and docker hub login server", "docker:///diego/image", "https://index.docker.io/v1/", true),
+		Entry("private registry and its login server", "docker://registry.example.com/diego/image", "https://registry.example.com", true),
+		Entry("private registry and docker hub login server", "docker://registry.example.com/diego/image", "https://index.docker.io/v1/", false),
+	)
+
+	It("parses the docker path of a task request", func() {
+		request := cc_messages.TaskRequestFromCC{DockerPath: "docker:///diego/image#v2"}
+		image, err := request.DockerImage()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image.String()).To(Equal("docker.io/diego/image:v2"))
+	})
+})
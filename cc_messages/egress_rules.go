@@ -0,0 +1,432 @@
+package cc_messages
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+const (
+	EgressProtocolTCP  = "tcp"
+	EgressProtocolUDP  = "udp"
+	EgressProtocolICMP = "icmp"
+	EgressProtocolAll  = "all"
+
+	// EgressICMPAny matches any ICMP type or code.
+	EgressICMPAny = -1
+)
+
+// EgressRules analyses the egress rules carried by desire, staging and task
+// requests. The rules are allow-only, so their order does not matter.
+type EgressRules []*models.SecurityGroupRule
+
+type EgressRuleError struct {
+	Index  int
+	Reason string
+}
+
+func (e EgressRuleError) Error() string {
+	return fmt.Sprintf("Invalid egress rule %d: %s", e.Index, e.Reason)
+}
+
+// EgressRuleRedundancy reports that the rule at Index allows no traffic that
+// the rule at CoveredBy does not already allow.
+type EgressRuleRedundancy struct {
+	Index     int
+	CoveredBy int
+}
+
+type ipRange struct {
+	start uint32
+	end   uint32
+}
+
+type portRange struct {
+	start uint32
+	end   uint32
+}
+
+var allPorts = []portRange{{start: 1, end: 65535}}
+
+// Validate returns an EgressRuleError for the first invalid rule.
+func (rules EgressRules) Validate() error {
+	for i, rule := range rules {
+		reason := validateEgressRule(rule)
+		if reason != "" {
+			return EgressRuleError{Index: i, Reason: reason}
+		}
+	}
+	return nil
+}
+
+func validateEgressRule(rule *models.SecurityGroupRule) string {
+	if rule == nil {
+		return "missing rule"
+	}
+
+	if len(rule.Destinations) == 0 {
+		return "no destinations"
+	}
+	for _, destination := range rule.Destinations {
+		if _, err := parseEgressDestination(destination); err != nil {
+			return err.Error()
+		}
+	}
+
+	switch rule.Protocol {
+	case EgressProtocolTCP, EgressProtocolUDP:
+		if len(rule.Ports) > 0 && rule.PortRange != nil {
+			return "ports and port range are mutually exclusive"
+		}
+		if len(rule.Ports) == 0 && rule.PortRange == nil {
+			return "ports or port range required for " + rule.Protocol
+		}
+		for _, port := range rule.Ports {
+			if port < 1 || port > 65535 {
+				return fmt.Sprintf("port %d out of range", port)
+			}
+		}
+		if rule.PortRange != nil {
+			if rule.PortRange.Start < 1 || rule.PortRange.End > 65535 || rule.PortRange.Start > rule.PortRange.End {
+				return fmt.Sprintf("port range %d-%d invalid", rule.PortRange.Start, rule.PortRange.End)
+			}
+		}
+		if rule.IcmpInfo != nil {
+			return "icmp info not allowed for " + rule.Protocol
+		}
+	case EgressProtocolICMP:
+		if rule.IcmpInfo == nil {
+			return "icmp info required for icmp"
+		}
+		if len(rule.Ports) > 0 || rule.PortRange != nil {
+			return "ports not allowed for icmp"
+		}
+	case EgressProtocolAll:
+		if len(rule.Ports) > 0 || rule.PortRange != nil {
+			return "ports not allowed for all"
+		}
+		if rule.IcmpInfo != nil {
+			return "icmp info not allowed for all"
+		}
+	default:
+		return fmt.Sprintf("unknown protocol '%s'", rule.Protocol)
+	}
+
+	return ""
+}
+
+// Allows reports whether any rule allows traffic to ip on the given protocol
+// and port. The port is ignored for icmp.
+func (rules EgressRules) Allows(ip net.IP, protocol string, port uint32) bool {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return false
+	}
+	address := binary.BigEndian.Uint32(ip4)
+
+	for _, rule := range rules {
+		if validateEgressRule(rule) != "" {
+			continue
+		}
+		if rule.Protocol != EgressProtocolAll && rule.Protocol != protocol {
+			continue
+		}
+		if !rangesCoverIP(destinationRanges(rule), address) {
+			continue
+		}
+		if protocol == EgressProtocolTCP || protocol == EgressProtocolUDP {
+			if !portRangesCover(rulePorts(rule), []portRange{{start: port, end: port}}) {
+				continue
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// Redundancies finds the rules that are shadowed by another rule. When two
+// rules cover each other, only the later one is reported. Invalid rules are
+// skipped.
+func (rules EgressRules) Redundancies() []EgressRuleRedundancy {
+	redundancies := []EgressRuleRedundancy{}
+
+	for i, rule := range rules {
+		if validateEgressRule(rule) != "" {
+			continue
+		}
+
+		for j, other := range rules {
+			if i == j || validateEgressRule(other) != "" || !egressRuleCovers(other, rule) {
+				continue
+			}
+			if j > i && egressRuleCovers(rule, other) {
+				continue
+			}
+
+			redundancies = append(redundancies, EgressRuleRedundancy{Index: i, CoveredBy: j})
+			break
+		}
+	}
+
+	return redundancies
+}
+
+// Merged combines rules that differ only in their destinations and collapses
+// overlapping and adjacent destinations. Invalid rules are kept unchanged.
+func (rules EgressRules) Merged() EgressRules {
+	merged := EgressRules{}
+	groups := map[string]int{}
+
+	for _, rule := range rules {
+		if validateEgressRule(rule) != "" {
+			merged = append(merged, rule)
+			continue
+		}
+
+		key := egressRuleKey(rule)
+		if i, ok := groups[key]; ok {
+			merged[i].Destinations = append(merged[i].Destinations, rule.Destinations...)
+			continue
+		}
+
+		copied := *rule
+		copied.Destinations = append([]string{}, rule.Destinations...)
+		groups[key] = len(merged)
+		merged = append(merged, &copied)
+	}
+
+	for _, rule := range merged {
+		if validateEgressRule(rule) == "" {
+			rule.Destinations = MergeEgressDestinations(rule.Destinations)
+		}
+	}
+
+	return merged
+}
+
+// MergeEgressDestinations collapses overlapping and adjacent destinations,
+// rendering each result as an address, a CIDR or an address range. Invalid
+// destinations are dropped.
+func MergeEgressDestinations(destinations []string) []string {
+	ranges := []ipRange{}
+	for _, destination := range destinations {
+		r, err := parseEgressDestination(destination)
+		if err == nil {
+			ranges = append(ranges, r)
+		}
+	}
+
+	merged := []string{}
+	for _, r := range mergeIPRanges(ranges) {
+		merged = append(merged, r.String())
+	}
+	return merged
+}
+
+func egressRuleKey(rule *models.SecurityGroupRule) string {
+	key := fmt.Sprintf("%s|%v|%t", rule.Protocol, rulePorts(rule), rule.Log)
+	if rule.IcmpInfo != nil {
+		key += fmt.Sprintf("|%d/%d", rule.IcmpInfo.Type, rule.IcmpInfo.Code)
+	}
+	return key
+}
+
+// egressRuleCovers reports whether every packet allowed by rule is allowed
+// by cover. A logged rule is only covered by another logged rule.
+func egressRuleCovers(cover, rule *models.SecurityGroupRule) bool {
+	if rule.Log && !cover.Log {
+		return false
+	}
+
+	switch {
+	case cover.Protocol == EgressProtocolAll:
+	case cover.Protocol != rule.Protocol:
+		return false
+	case rule.Protocol == EgressProtocolICMP:
+		if !icmpCovers(cover.IcmpInfo.Type, rule.IcmpInfo.Type) || !icmpCovers(cover.IcmpInfo.Code, rule.IcmpInfo.Code) {
+			return false
+		}
+	default:
+		if !portRangesCover(rulePorts(cover), rulePorts(rule)) {
+			return false
+		}
+	}
+
+	coverRanges := destinationRanges(cover)
+	for _, r := range destinationRanges(rule) {
+		if !rangesCover(coverRanges, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func icmpCovers(cover, value int32) bool {
+	return cover == EgressICMPAny || cover == value
+}
+
+func rulePorts(rule *models.SecurityGroupRule) []portRange {
+	if rule.Protocol != EgressProtocolTCP && rule.Protocol != EgressProtocolUDP {
+		return allPorts
+	}
+
+	ports := []portRange{}
+	for _, port := range rule.Ports {
+		ports = append(ports, portRange{start: port, end: port})
+	}
+	if rule.PortRange != nil {
+		ports = append(ports, portRange{start: rule.PortRange.Start, end: rule.PortRange.End})
+	}
+
+	sort.Sort(portRanges(ports))
+	merged := []portRange{}
+	for _, p := range ports {
+		last := len(merged) - 1
+		if last >= 0 && p.start <= merged[last].end+1 {
+			if p.end > merged[last].end {
+				merged[last].end = p.end
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+func portRangesCover(cover, ports []portRange) bool {
+	for _, p := range ports {
+		covered := false
+		for _, c := range cover {
+			if c.start <= p.start && p.end <= c.end {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func destinationRanges(rule *models.SecurityGroupRule) []ipRange {
+	ranges := []ipRange{}
+	for _, destination := range rule.Destinations {
+		r, err := parseEgressDestination(destination)
+		if err == nil {
+			ranges = append(ranges, r)
+		}
+	}
+	return mergeIPRanges(ranges)
+}
+
+func rangesCoverIP(ranges []ipRange, address uint32) bool {
+	return rangesCover(ranges, ipRange{start: address, end: address})
+}
+
+// rangesCover expects merged ranges, so a covered range must lie within a
+// single one of them.
+func rangesCover(ranges []ipRange, r ipRange) bool {
+	for _, c := range ranges {
+		if c.start <= r.start && r.end <= c.end {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeIPRanges(ranges []ipRange) []ipRange {
+	sorted := append([]ipRange{}, ranges...)
+	sort.Sort(ipRanges(sorted))
+
+	merged := []ipRange{}
+	for _, r := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && (r.start <= merged[last].end || r.start == merged[last].end+1) {
+			if r.end > merged[last].end {
+				merged[last].end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// parseEgressDestination accepts the destination forms CC allows: a single
+// IPv4 address, a CIDR or an inclusive address range such as
+// 10.0.0.1-10.0.0.255.
+func parseEgressDestination(destination string) (ipRange, error) {
+	if strings.Contains(destination, "/") {
+		_, network, err := net.ParseCIDR(destination)
+		if err != nil || network.IP.To4() == nil {
+			return ipRange{}, fmt.Errorf("invalid destination '%s'", destination)
+		}
+		ones, _ := network.Mask.Size()
+		start := binary.BigEndian.Uint32(network.IP.To4())
+		return ipRange{start: start, end: start | (uint32(0xffffffff) >> uint(ones))}, nil
+	}
+
+	parts := strings.SplitN(destination, "-", 2)
+	start, ok := parseIPv4(parts[0])
+	if !ok {
+		return ipRange{}, fmt.Errorf("invalid destination '%s'", destination)
+	}
+	if len(parts) == 1 {
+		return ipRange{start: start, end: start}, nil
+	}
+
+	end, ok := parseIPv4(parts[1])
+	if !ok || end < start {
+		return ipRange{}, fmt.Errorf("invalid destination '%s'", destination)
+	}
+	return ipRange{start: start, end: end}, nil
+}
+
+func parseIPv4(address string) (uint32, bool) {
+	ip := net.ParseIP(strings.TrimSpace(address)).To4()
+	if ip == nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(ip), true
+}
+
+func formatIPv4(address uint32) string {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, address)
+	return ip.String()
+}
+
+func (r ipRange) String() string {
+	if r.start == r.end {
+		return formatIPv4(r.start)
+	}
+
+	size := uint64(r.end) - uint64(r.start) + 1
+	if size&(size-1) == 0 && uint64(r.start)%size == 0 {
+		ones := 32
+		for s := size; s > 1; s >>= 1 {
+			ones--
+		}
+		return fmt.Sprintf("%s/%d", formatIPv4(r.start), ones)
+	}
+
+	return formatIPv4(r.start) + "-" + formatIPv4(r.end)
+}
+
+type ipRanges []ipRange
+
+func (r ipRanges) Len() int           { return len(r) }
+func (r ipRanges) Less(i, j int) bool { return r[i].start < r[j].start }
+func (r ipRanges) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+type portRanges []portRange
+
+func (p portRanges) Len() int           { return len(p) }
+func (p portRanges) Less(i, j int) bool { return p[i].start < p[j].start }
+func (p portRanges) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
@@ -0,0 +1,161 @@
+package cc_messages_test
+
+import (
+	"encoding/json"
+	"net"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EgressRules", func() {
+	Describe("Validate", func() {
+		It("accepts valid rules", func() {
+			rules := cc_messages.EgressRules{
+				{Protocol: "tcp", Destinations: []string{"10.0.0.0/8"}, Ports: []uint32{80, 443}},
+				{Protocol: "udp", Destinations: []string{"10.0.0.1-10.0.0.9"}, PortRange: &models.PortRange{Start: 53, End: 53}},
+				{Protocol: "icmp", Destinations: []string{"8.8.8.8"}, IcmpInfo: &models.ICMPInfo{Type: -1, Code: -1}},
+				{Protocol: "all", Destinations: []string{"0.0.0.0-255.255.255.255"}},
+			}
+			Expect(rules.Validate()).To(Succeed())
+		})
+
+		It("reports the first invalid rule", func() {
+			rules := cc_messages.EgressRules{
+				{Protocol: "all", Destinations: []string{"10.0.0.0/8"}},
+				{Protocol: "tcp", Destinations: []string{"10.0.0.0/33"}, Ports: []uint32{80}},
+			}
+
+			err := rules.Validate()
+			Expect(err).To(BeAssignableToTypeOf(cc_messages.EgressRuleError{}))
+			Expect(err.(cc_messages.EgressRuleError).Index).To(Equal(1))
+		})
+
+		It("rejects invalid ports and protocols", func() {
+			invalid := []*models.SecurityGroupRule{
+				{Protocol: "tcp", Destinations: []string{"10.0.0.1"}},
+				{Protocol: "tcp", Destinations: []string{"10.0.0.1"}, Ports: []uint32{0}},
+				{Protocol: "tcp", Destinations: []string{"10.0.0.1"}, PortRange: &models.PortRange{Start: 90, End: 80}},
+				{Protocol: "tcp", Destinations: []string{"10.0.0.1"}, Ports: []uint32{80}, PortRange: &models.PortRange{Start: 80, End: 90}},
+				{Protocol: "icmp", Destinations: []string{"10.0.0.1"}},
+				{Protocol: "all", Destinations: []string{"10.0.0.1"}, Ports: []uint32{80}},
+				{Protocol: "sctp", Destinations: []string{"10.0.0.1"}},
+				{Protocol: "all", Destinations: []string{"10.0.0.9-10.0.0.1"}},
+				{Protocol: "all"},
+			}
+
+			for _, rule := range invalid {
+				Expect(cc_messages.EgressRules{rule}.Validate()).To(HaveOccurred())
+			}
+		})
+	})
+
+	Describe("Allows", func() {
+		var rules cc_messages.EgressRules
+
+		BeforeEach(func() {
+			rules = cc_messages.EgressRules{
+				{Protocol: "tcp", Destinations: []string{"10.0.0.0/24"}, Ports: []uint32{443}},
+				{Protocol: "udp", Destinations: []string{"8.8.8.8"}, PortRange: &models.PortRange{Start: 53, End: 54}},
+				{Protocol: "icmp", Destinations: []string{"8.8.4.4"}, IcmpInfo: &models.ICMPInfo{Type: 0, Code: 0}},
+			}
+		})
+
+		It("answers whether traffic is allowed", func() {
+			Expect(rules.Allows(net.ParseIP("10.0.0.42"), "tcp", 443)).To(BeTrue())
+			Expect(rules.Allows(net.ParseIP("10.0.0.42"), "tcp", 80)).To(BeFalse())
+			Expect(rules.Allows(net.ParseIP("10.0.1.42"), "tcp", 443)).To(BeFalse())
+			Expect(rules.Allows(net.ParseIP("10.0.0.42"), "udp", 443)).To(BeFalse())
+			Expect(rules.Allows(net.ParseIP("8.8.8.8"), "udp", 54)).To(BeTrue())
+			Expect(rules.Allows(net.ParseIP("8.8.4.4"), "icmp", 0)).To(BeTrue())
+		})
+
+		It("allows everything covered by an all rule", func() {
+			rules = append(rules, &models.SecurityGroupRule{Protocol: "all", Destinations: []string{"192.168.0.0/16"}})
+			Expect(rules.Allows(net.ParseIP("192.168.1.1"), "tcp", 8080)).To(BeTrue())
+		})
+
+		It("can audit a dumped CC payload", func() {
+			var request cc_messages.TaskRequestFromCC
+			err := json.Unmarshal([]byte(`{"egress_rules":[{"protocol":"tcp","destinations":["10.0.0.0/8"],"ports":[5432]}]}`), &request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cc_messages.EgressRules(request.EgressRules).Allows(net.ParseIP("10.1.2.3"), "tcp", 5432)).To(BeTrue())
+		})
+	})
+
+	Describe("Redundancies", func() {
+		It("finds rules shadowed by broader rules", func() {
+			rules := cc_messages.EgressRules{
+				{Protocol: "tcp", Destinations: []string{"10.0.0.5"}, Ports: []uint32{80}},
+				{Protocol: "tcp", Destinations: []string{"10.0.0.0/24"}, PortRange: &models.PortRange{Start: 1, End: 1024}},
+				{Protocol: "all", Destinations: []string{"192.168.0.0/16"}},
+				{Protocol: "udp", Destinations: []string{"192.168.1.1"}, Ports: []uint32{53}},
+				{Protocol: "udp", Destinations: []string{"172.16.0.1"}, Ports: []uint32{53}},
+			}
+
+			Expect(rules.Redundancies()).To(Equal([]cc_messages.EgressRuleRedundancy{
+				{Index: 0, CoveredBy: 1},
+				{Index: 3, CoveredBy: 2},
+			}))
+		})
+
+		It("reports only the later of two duplicate rules", func() {
+			rules := cc_messages.EgressRules{
+				{Protocol: "all", Destinations: []string{"10.0.0.0/8"}},
+				{Protocol: "all", Destinations: []string{"10.0.0.0-10.255.255.255"}},
+			}
+
+			Expect(rules.Redundancies()).To(Equal([]cc_messages.EgressRuleRedundancy{
+				{Index: 1, CoveredBy: 0},
+			}))
+		})
+
+		It("does not treat a logged rule as covered by an unlogged one", func() {
+			rules := cc_messages.EgressRules{
+				{Protocol: "all", Destinations: []string{"10.0.0.0/8"}},
+				{Protocol: "all", Destinations: []string{"10.0.0.1"}, Log: true},
+			}
+
+			Expect(rules.Redundancies()).To(BeEmpty())
+		})
+	})
+
+	Describe("Merged", func() {
+		It("merges the destinations of otherwise identical rules", func() {
+			rules := cc_messages.EgressRules{
+				{Protocol: "tcp", Destinations: []string{"10.0.0.0/25"}, Ports: []uint32{443}},
+				{Protocol: "tcp", Destinations: []string{"10.0.0.128-10.0.0.255", "10.0.1.7"}, Ports: []uint32{443}},
+				{Protocol: "tcp", Destinations: []string{"10.0.0.0/24"}, Ports: []uint32{80}},
+			}
+
+			Expect(rules.Merged()).To(Equal(cc_messages.EgressRules{
+				{Protocol: "tcp", Destinations: []string{"10.0.0.0/24", "10.0.1.7"}, Ports: []uint32{443}},
+				{Protocol: "tcp", Destinations: []string{"10.0.0.0/24"}, Ports: []uint32{80}},
+			}))
+		})
+
+		It("does not modify the original rules", func() {
+			rules := cc_messages.EgressRules{
+				{Protocol: "all", Destinations: []string{"10.0.0.1", "10.0.0.2"}},
+			}
+			rules.Merged()
+			Expect(rules[0].Destinations).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+		})
+	})
+
+	Describe("MergeEgressDestinations", func() {
+		It("collapses overlapping and adjacent destinations", func() {
+			Expect(cc_messages.MergeEgressDestinations([]string{
+				"10.0.0.3", "10.0.0.0-10.0.0.2", "10.0.0.8/29", "10.0.0.12", "0.0.0.0-255.255.255.255",
+			})).To(Equal([]string{"0.0.0.0/0"}))
+
+			Expect(cc_messages.MergeEgressDestinations([]string{
+				"10.0.0.3", "10.0.0.0-10.0.0.2", "10.0.0.5-10.0.0.9",
+			})).To(Equal([]string{"10.0.0.0/30", "10.0.0.5-10.0.0.9"}))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package cc_messages
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+// SystemEnvironmentVariables are set by the platform and may not be
+// overridden by user-provided environment. Entries are glob patterns as
+// understood by filepath.Match.
+var SystemEnvironmentVariables = []string{
+	"VCAP_*",
+	"CF_INSTANCE_*",
+	"INSTANCE_GUID",
+	"INSTANCE_INDEX",
+	"MEMORY_LIMIT",
+	"PORT",
+}
+
+var environmentVariableNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type InvalidEnvironmentVariableNameError struct {
+	Name string
+}
+
+func (e InvalidEnvironmentVariableNameError) Error() string {
+	return "Invalid environment variable name: '" + e.Name + "' is not a valid POSIX identifier"
+}
+
+func IsSystemEnvironmentVariable(name string) bool {
+	for _, pattern := range SystemEnvironmentVariables {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateEnvironment checks that every name is a valid POSIX identifier.
+func ValidateEnvironment(env []*models.EnvironmentVariable) error {
+	for _, envVar := range env {
+		if envVar == nil {
+			continue
+		}
+		if !environmentVariableNameRegexp.MatchString(envVar.Name) {
+			return InvalidEnvironmentVariableNameError{Name: envVar.Name}
+		}
+	}
+	return nil
+}
+
+// LookupEnvironment returns the value of the last variable with the given
+// name, matching what a process sees when the list is exported in order.
+func LookupEnvironment(env []*models.EnvironmentVariable, name string) (string, bool) {
+	value, found := "", false
+	for _, envVar := range env {
+		if envVar != nil && envVar.Name == name {
+			value, found = envVar.Value, true
+		}
+	}
+	return value, found
+}
+
+// DeduplicateEnvironment keeps the last value of every name, at the position
+// where the name first appeared.
+func DeduplicateEnvironment(env []*models.EnvironmentVariable) []*models.EnvironmentVariable {
+	return MergeEnvironment(env)
+}
+
+// MergeEnvironment combines the layers so that later layers take precedence
+// over earlier ones. The result is de-duplicated and keeps the order in which
+// names first appeared.
+func MergeEnvironment(layers ...[]*models.EnvironmentVariable) []*models.EnvironmentVariable {
+	merged := []*models.EnvironmentVariable{}
+	positions := map[string]int{}
+
+	for _, layer := range layers {
+		for _, envVar := range layer {
+			if envVar == nil {
+				continue
+			}
+
+			copied := *envVar
+			if i, ok := positions[copied.Name]; ok {
+				merged[i] = &copied
+				continue
+			}
+
+			positions[copied.Name] = len(merged)
+			merged = append(merged, &copied)
+		}
+	}
+
+	return merged
+}
+
+// MergeUserEnvironment applies the user-provided environment on top of the
+// system environment, dropping any user variable that would override a
+// system variable.
+func MergeUserEnvironment(system, user []*models.EnvironmentVariable) []*models.EnvironmentVariable {
+	allowed := []*models.EnvironmentVariable{}
+	for _, envVar := range user {
+		if envVar != nil && !IsSystemEnvironmentVariable(envVar.Name) {
+			allowed = append(allowed, envVar)
+		}
+	}
+
+	return MergeEnvironment(system, allowed)
+}
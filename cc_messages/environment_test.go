@@ -0,0 +1,149 @@
+package cc_messages_test
+
+import (
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Environment", func() {
+	Describe("ValidateEnvironment", func() {
+		It("accepts POSIX identifiers", func() {
+			env := []*models.EnvironmentVariable{{Name: "FOO"}, {Name: "_bar_1"}}
+			Expect(cc_messages.ValidateEnvironment(env)).To(Succeed())
+		})
+
+		It("rejects names that are not POSIX identifiers", func() {
+			for _, name := range []string{"", "1FOO", "FOO-BAR", "FOO BAR", "FOO=BAR"} {
+				env := []*models.EnvironmentVariable{{Name: name}}
+				Expect(cc_messages.ValidateEnvironment(env)).To(Equal(cc_messages.InvalidEnvironmentVariableNameError{Name: name}))
+			}
+		})
+	})
+
+	Describe("LookupEnvironment", func() {
+		It("returns the last value for the name", func() {
+			env := []*models.EnvironmentVariable{{Name: "FOO", Value: "1"}, {Name: "FOO", Value: "2"}}
+
+			value, ok := cc_messages.LookupEnvironment(env, "FOO")
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal("2"))
+
+			_, ok = cc_messages.LookupEnvironment(env, "BAR")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("MergeEnvironment", func() {
+		It("lets later layers win and keeps the first-seen order", func() {
+			base := []*models.EnvironmentVariable{{Name: "A", Value: "1"}, {Name: "B", Value: "1"}}
+			override := []*models.EnvironmentVariable{{Name: "C", Value: "2"}, {Name: "A", Value: "2"}}
+
+			Expect(cc_messages.MergeEnvironment(base, override)).To(Equal([]*models.EnvironmentVariable{
+				{Name: "A", Value: "2"},
+				{Name: "B", Value: "1"},
+				{Name: "C", Value: "2"},
+			}))
+		})
+
+		It("does not modify the layers", func() {
+			base := []*models.EnvironmentVariable{{Name: "A", Value: "1"}}
+			cc_messages.MergeEnvironment(base, []*models.EnvironmentVariable{{Name: "A", Value: "2"}})
+			Expect(base[0].Value).To(Equal("1"))
+		})
+	})
+
+	Describe("DeduplicateEnvironment", func() {
+		It("keeps the last value of each name", func() {
+			env := []*models.EnvironmentVariable{{Name: "A", Value: "1"}, {Name: "B"}, {Name: "A", Value: "3"}}
+			Expect(cc_messages.DeduplicateEnvironment(env)).To(Equal([]*models.EnvironmentVariable{
+				{Name: "A", Value: "3"},
+				{Name: "B"},
+			}))
+		})
+	})
+
+	Describe("MergeUserEnvironment", func() {
+		It("does not let user variables override system variables", func() {
+			system := []*models.EnvironmentVariable{{Name: "VCAP_APPLICATION", Value: "{}"}, {Name: "PORT", Value: "8080"}}
+			user := []*models.EnvironmentVariable{
+				{Name: "PORT", Value: "9090"},
+				{Name: "CF_INSTANCE_IP", Value: "1.2.3.4"},
+				{Name: "FOO", Value: "BAR"},
+			}
+
+			Expect(cc_messages.MergeUserEnvironment(system, user)).To(Equal([]*models.EnvironmentVariable{
+				{Name: "VCAP_APPLICATION", Value: "{}"},
+				{Name: "PORT", Value: "8080"},
+				{Name: "FOO", Value: "BAR"},
+			}))
+		})
+	})
+
+	Describe("VCAP_APPLICATION", func() {
+		It("decodes the typed application", func() {
+			env := []*models.EnvironmentVariable{{
+				Name:  "VCAP_APPLICATION",
+				Value: `{"application_id":"app-guid","application_name":"my-app","application_uris":["my-app.example.com"],"limits":{"mem":256,"disk":1024,"fds":16384},"space_name":"dev"}`,
+			}}
+
+			vcapApplication, err := cc_messages.VcapApplicationFromEnvironment(env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vcapApplication.ApplicationId).To(Equal("app-guid"))
+			Expect(vcapApplication.ApplicationName).To(Equal("my-app"))
+			Expect(vcapApplication.ApplicationUris).To(ConsistOf("my-app.example.com"))
+			Expect(vcapApplication.Limits).To(Equal(cc_messages.VcapApplicationLimits{Mem: 256, Disk: 1024, Fds: 16384}))
+			Expect(vcapApplication.SpaceName).To(Equal("dev"))
+		})
+
+		It("errors when it is missing", func() {
+			_, err := cc_messages.VcapApplicationFromEnvironment(nil)
+			Expect(err).To(Equal(cc_messages.ErrVcapApplicationMissing))
+		})
+
+		It("errors when it is not valid JSON", func() {
+			env := []*models.EnvironmentVariable{{Name: "VCAP_APPLICATION", Value: "{"}}
+			_, err := cc_messages.VcapApplicationFromEnvironment(env)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("VCAP_SERVICES", func() {
+		var env []*models.EnvironmentVariable
+
+		BeforeEach(func() {
+			env = []*models.EnvironmentVariable{{
+				Name: "VCAP_SERVICES",
+				Value: `{
+					"p-mysql": [{"name": "db", "label": "p-mysql", "tags": ["mysql", "relational"], "plan": "small", "credentials": {"uri": "mysql://db"}}],
+					"user-provided": [{"name": "logs", "label": "user-provided", "tags": [], "credentials": {}, "syslog_drain_url": "syslog://logs"}]
+				}`,
+			}}
+		})
+
+		It("finds services by name, label and tag", func() {
+			services, err := cc_messages.VcapServicesFromEnvironment(env)
+			Expect(err).NotTo(HaveOccurred())
+
+			db, ok := services.FindByName("db")
+			Expect(ok).To(BeTrue())
+			Expect(db.Credentials).To(HaveKeyWithValue("uri", "mysql://db"))
+
+			_, ok = services.FindByName("missing")
+			Expect(ok).To(BeFalse())
+
+			Expect(services.FindByLabel("user-provided")).To(HaveLen(1))
+			Expect(services.FindByLabel("user-provided")[0].SyslogDrainUrl).To(Equal("syslog://logs"))
+
+			Expect(services.FindByTag("mysql")).To(Equal([]cc_messages.VcapService{db}))
+		})
+
+		It("decodes to no services when it is missing", func() {
+			services, err := cc_messages.VcapServicesFromEnvironment(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(services).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,106 @@
+package cc_messages
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const DefaultAppPort uint32 = 8080
+
+// ExecutionMetadata is the typed form of DesireAppRequestFromCC.ExecutionMetadata.
+// Buildpack staging only sets StartCommand; docker staging records the image
+// configuration.
+type ExecutionMetadata struct {
+	StartCommand string                  `json:"start_command,omitempty"`
+	Entrypoint   []string                `json:"entrypoint,omitempty"`
+	Cmd          []string                `json:"cmd,omitempty"`
+	Workdir      string                  `json:"workdir,omitempty"`
+	User         string                  `json:"user,omitempty"`
+	Ports        []ExecutionMetadataPort `json:"ports,omitempty"`
+}
+
+// ExecutionMetadataPort keeps the capitalised keys written by the docker
+// lifecycle.
+type ExecutionMetadataPort struct {
+	Port     uint16 `json:"Port"`
+	Protocol string `json:"Protocol"`
+}
+
+func ParseExecutionMetadata(executionMetadata string) (ExecutionMetadata, error) {
+	metadata := ExecutionMetadata{}
+	if strings.TrimSpace(executionMetadata) == "" {
+		return metadata, nil
+	}
+
+	err := json.Unmarshal([]byte(executionMetadata), &metadata)
+	if err != nil {
+		return ExecutionMetadata{}, err
+	}
+	return metadata, nil
+}
+
+func (m ExecutionMetadata) Serialize() (string, error) {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+// TCPPorts returns the exposed tcp ports in the order they were declared.
+func (m ExecutionMetadata) TCPPorts() []uint32 {
+	ports := []uint32{}
+	for _, port := range m.Ports {
+		if port.Protocol == "" || strings.ToLower(port.Protocol) == "tcp" {
+			ports = append(ports, uint32(port.Port))
+		}
+	}
+	return ports
+}
+
+func (r DesireAppRequestFromCC) ParseExecutionMetadata() (ExecutionMetadata, error) {
+	return ParseExecutionMetadata(r.ExecutionMetadata)
+}
+
+// ResolveCommand resolves the argv the app runs with. The user's start
+// command wins, then the staged command for the process type, then the
+// staged start command, all run through a shell; otherwise the image's
+// entrypoint followed by its cmd is used.
+func (r DesireAppRequestFromCC) ResolveCommand() ([]string, error) {
+	if command := r.ProcessTypes.StartCommand(r.ProcessType, r.StartCommand); command != "" {
+		return []string{"/bin/sh", "-c", command}, nil
+	}
+
+	metadata, err := r.ParseExecutionMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata.StartCommand != "" {
+		return []string{"/bin/sh", "-c", metadata.StartCommand}, nil
+	}
+
+	command := append([]string{}, metadata.Entrypoint...)
+	return append(command, metadata.Cmd...), nil
+}
+
+// EffectivePorts defaults Ports: explicit ports win, then the tcp ports a
+// docker image exposes, then DefaultAppPort.
+func (r DesireAppRequestFromCC) EffectivePorts() ([]uint32, error) {
+	if len(r.Ports) > 0 {
+		return r.Ports, nil
+	}
+
+	if r.DockerImageUrl != "" {
+		metadata, err := r.ParseExecutionMetadata()
+		if err != nil {
+			return nil, err
+		}
+
+		if ports := metadata.TCPPorts(); len(ports) > 0 {
+			return ports, nil
+		}
+	}
+
+	return []uint32{DefaultAppPort}, nil
+}
@@ -0,0 +1,136 @@
+package cc_messages_test
+
+import (
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExecutionMetadata", func() {
+	dockerMetadata := `{
+		"entrypoint": ["/docker-entrypoint.sh"],
+		"cmd": ["nginx", "-g", "daemon off;"],
+		"workdir": "/app",
+		"user": "nginx",
+		"ports": [{"Port": 80, "Protocol": "tcp"}, {"Port": 53, "Protocol": "udp"}, {"Port": 443, "Protocol": "tcp"}]
+	}`
+
+	Describe("ParseExecutionMetadata", func() {
+		It("parses docker metadata", func() {
+			metadata, err := cc_messages.ParseExecutionMetadata(dockerMetadata)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata).To(Equal(cc_messages.ExecutionMetadata{
+				Entrypoint: []string{"/docker-entrypoint.sh"},
+				Cmd:        []string{"nginx", "-g", "daemon off;"},
+				Workdir:    "/app",
+				User:       "nginx",
+				Ports: []cc_messages.ExecutionMetadataPort{
+					{Port: 80, Protocol: "tcp"},
+					{Port: 53, Protocol: "udp"},
+					{Port: 443, Protocol: "tcp"},
+				},
+			}))
+		})
+
+		It("parses buildpack metadata", func() {
+			metadata, err := cc_messages.ParseExecutionMetadata(`{"start_command":"rackup"}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata).To(Equal(cc_messages.ExecutionMetadata{StartCommand: "rackup"}))
+		})
+
+		It("treats empty metadata as no metadata", func() {
+			metadata, err := cc_messages.ParseExecutionMetadata("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata).To(Equal(cc_messages.ExecutionMetadata{}))
+		})
+
+		It("errors on invalid JSON", func() {
+			_, err := cc_messages.ParseExecutionMetadata("{")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("serializes back to the same JSON", func() {
+		metadata, err := cc_messages.ParseExecutionMetadata(dockerMetadata)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(metadata.Serialize()).To(MatchJSON(dockerMetadata))
+	})
+
+	Describe("ResolveCommand", func() {
+		var request cc_messages.DesireAppRequestFromCC
+
+		BeforeEach(func() {
+			request = cc_messages.DesireAppRequestFromCC{
+				StartCommand:      "./custom",
+				ProcessType:       "worker",
+				ProcessTypes:      cc_messages.ProcessTypes{"web": "rackup", "worker": "rake jobs:work"},
+				ExecutionMetadata: `{"start_command": "bundle exec rackup", "entrypoint": ["/docker-entrypoint.sh"], "cmd": ["nginx"]}`,
+			}
+		})
+
+		It("prefers the user command", func() {
+			Expect(request.ResolveCommand()).To(Equal([]string{"/bin/sh", "-c", "./custom"}))
+		})
+
+		It("then uses the staged command for the process type", func() {
+			request.StartCommand = ""
+			Expect(request.ResolveCommand()).To(Equal([]string{"/bin/sh", "-c", "rake jobs:work"}))
+
+			request.ProcessType = ""
+			Expect(request.ResolveCommand()).To(Equal([]string{"/bin/sh", "-c", "rackup"}))
+		})
+
+		It("then uses the staged start command", func() {
+			request.StartCommand = ""
+			request.ProcessTypes = nil
+			Expect(request.ResolveCommand()).To(Equal([]string{"/bin/sh", "-c", "bundle exec rackup"}))
+		})
+
+		It("then uses the image's entrypoint and cmd", func() {
+			request.StartCommand = ""
+			request.ProcessTypes = nil
+			request.ExecutionMetadata = dockerMetadata
+			Expect(request.ResolveCommand()).To(Equal([]string{"/docker-entrypoint.sh", "nginx", "-g", "daemon off;"}))
+		})
+
+		It("errors on malformed execution metadata", func() {
+			request.StartCommand = ""
+			request.ProcessTypes = nil
+			request.ExecutionMetadata = "{"
+			_, err := request.ResolveCommand()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("EffectivePorts", func() {
+		It("prefers the requested ports", func() {
+			request := cc_messages.DesireAppRequestFromCC{
+				DockerImageUrl:    "docker:///nginx",
+				ExecutionMetadata: dockerMetadata,
+				Ports:             []uint32{9090},
+			}
+			Expect(request.EffectivePorts()).To(Equal([]uint32{9090}))
+		})
+
+		It("uses the tcp ports exposed by a docker image", func() {
+			request := cc_messages.DesireAppRequestFromCC{
+				DockerImageUrl:    "docker:///nginx",
+				ExecutionMetadata: dockerMetadata,
+			}
+			Expect(request.EffectivePorts()).To(Equal([]uint32{80, 443}))
+		})
+
+		It("falls back to the default port", func() {
+			request := cc_messages.DesireAppRequestFromCC{ExecutionMetadata: `{"start_command":"rackup"}`}
+			Expect(request.EffectivePorts()).To(Equal([]uint32{cc_messages.DefaultAppPort}))
+		})
+
+		It("errors when docker metadata cannot be parsed", func() {
+			request := cc_messages.DesireAppRequestFromCC{DockerImageUrl: "docker:///nginx", ExecutionMetadata: "{"}
+			_, err := request.EffectivePorts()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
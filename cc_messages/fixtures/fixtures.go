@@ -0,0 +1,105 @@
+package fixtures
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+)
+
+const CurrentVersion = "v1"
+
+// corpus is compiled in so that importers can verify against it wherever
+// their build places this package's source.
+//
+//go:embed v1
+var corpus embed.FS
+
+type Fixture struct {
+	File       string
+	NewMessage func() interface{}
+}
+
+var Fixtures = []Fixture{
+	{"desire_app_buildpack.json", func() interface{} { return &cc_messages.DesireAppRequestFromCC{} }},
+	{"desire_app_docker.json", func() interface{} { return &cc_messages.DesireAppRequestFromCC{} }},
+	{"desire_app_deployment_strategy.json", func() interface{} { return &cc_messages.DesireAppRequestFromCC{} }},
+	{"desire_app_placement.json", func() interface{} { return &cc_messages.DesireAppRequestFromCC{} }},
+	{"desire_app_process_types.json", func() interface{} { return &cc_messages.DesireAppRequestFromCC{} }},
+	{"desire_app_sidecars.json", func() interface{} { return &cc_messages.DesireAppRequestFromCC{} }},
+	{"staging_request_buildpack.json", func() interface{} { return &cc_messages.StagingRequestFromCC{} }},
+	{"staging_request_docker.json", func() interface{} { return &cc_messages.StagingRequestFromCC{} }},
+	{"staging_request_placement.json", func() interface{} { return &cc_messages.StagingRequestFromCC{} }},
+	{"buildpack_staging_data.json", func() interface{} { return &cc_messages.BuildpackStagingData{} }},
+	{"docker_staging_data.json", func() interface{} { return &cc_messages.DockerStagingData{} }},
+	{"staging_response_success.json", func() interface{} { return &cc_messages.StagingResponseForCC{} }},
+	{"staging_response_error.json", func() interface{} { return &cc_messages.StagingResponseForCC{} }},
+	{"staging_response_error_placement.json", func() interface{} { return &cc_messages.StagingResponseForCC{} }},
+	{"staging_task_annotation.json", func() interface{} { return &cc_messages.StagingTaskAnnotation{} }},
+	{"task_request.json", func() interface{} { return &cc_messages.TaskRequestFromCC{} }},
+	{"task_request_placement.json", func() interface{} { return &cc_messages.TaskRequestFromCC{} }},
+	{"task_fail_response.json", func() interface{} { return &cc_messages.TaskFailResponseForCC{} }},
+	{"task_error.json", func() interface{} { return &cc_messages.TaskError{} }},
+	{"task_error_placement.json", func() interface{} { return &cc_messages.TaskError{} }},
+	{"desired_state_fingerprints.json", func() interface{} { return &cc_messages.CCDesiredStateFingerprintResponse{} }},
+	{"task_states.json", func() interface{} { return &cc_messages.CCTaskStatesResponse{} }},
+	{"app_crashed.json", func() interface{} { return &cc_messages.AppCrashedRequest{} }},
+	{"lrp_instance.json", func() interface{} { return &cc_messages.LRPInstance{} }},
+}
+
+// Read returns a fixture from the given corpus version.
+func Read(version, file string) ([]byte, error) {
+	return corpus.ReadFile(path.Join(version, file))
+}
+
+// Verify round-trips every fixture of the given corpus version through its
+// message type.
+func Verify(version string) error {
+	for _, fixture := range Fixtures {
+		payload, err := Read(version, fixture.File)
+		if err != nil {
+			return err
+		}
+
+		err = RoundTrip(payload, fixture.NewMessage())
+		if err != nil {
+			return fmt.Errorf("%s: %s", fixture.File, err)
+		}
+	}
+
+	return nil
+}
+
+// RoundTrip unmarshals the payload into message, marshals it back and checks
+// that the result is equivalent JSON.
+func RoundTrip(payload []byte, message interface{}) error {
+	err := json.Unmarshal(payload, message)
+	if err != nil {
+		return err
+	}
+
+	remarshalled, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	var expected, actual interface{}
+	err = json.Unmarshal(payload, &expected)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(remarshalled, &actual)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		return fmt.Errorf("re-marshalled JSON differs:\nexpected: %s\nactual:   %s", payload, remarshalled)
+	}
+
+	return nil
+}
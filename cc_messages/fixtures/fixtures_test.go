@@ -0,0 +1,51 @@
+package fixtures_test
+
+import (
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages/fixtures"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fixtures", func() {
+	Describe("Verify", func() {
+		It("round-trips every fixture in the current corpus", func() {
+			Expect(fixtures.Verify(fixtures.CurrentVersion)).To(Succeed())
+		})
+
+		It("errors when the corpus version does not exist", func() {
+			Expect(fixtures.Verify("v0")).NotTo(Succeed())
+		})
+	})
+
+	Describe("Read", func() {
+		It("reads fixtures from the embedded corpus", func() {
+			payload, err := fixtures.Read(fixtures.CurrentVersion, "task_states.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(payload).NotTo(BeEmpty())
+		})
+
+		It("errors when the fixture does not exist", func() {
+			_, err := fixtures.Read(fixtures.CurrentVersion, "missing.json")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("RoundTrip", func() {
+		It("succeeds when the message preserves every field", func() {
+			err := fixtures.RoundTrip([]byte(`{"process_guid":"guid","etag":"etag"}`), &cc_messages.CCDesiredAppFingerprint{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("errors when the message drops a field", func() {
+			err := fixtures.RoundTrip([]byte(`{"process_guid":"guid","etag":"etag","extra":true}`), &cc_messages.CCDesiredAppFingerprint{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("errors when the payload is not valid JSON", func() {
+			err := fixtures.RoundTrip([]byte(`{`), &cc_messages.CCDesiredAppFingerprint{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
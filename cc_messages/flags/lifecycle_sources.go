@@ -0,0 +1,231 @@
+package flags
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LifecycleEnvPrefix marks environment variables that hold a lifecycle, as
+// 'lifecycle-name:path'. The rest of the variable name is only a label, as
+// lifecycle names such as 'buildpack/cflinuxfs2' cannot be variable names.
+const LifecycleEnvPrefix = "LIFECYCLE_"
+
+const LifecycleSourceFlag = "flag"
+
+// LoadedLifecycles are lifecycles merged from several sources, with the
+// source each one was finally taken from.
+type LoadedLifecycles struct {
+	Lifecycles LifecycleMap
+	Sources    map[string]string
+}
+
+// LoadLifecycles merges lifecycles from, in increasing precedence, the files
+// in order, the LIFECYCLE_* variables in environ and the flags. environ is
+// in the form returned by os.Environ. A lifecycle given twice within one file
+// or by two variables is an error.
+func LoadLifecycles(files []string, environ []string, flagLifecycles LifecycleMap) (LoadedLifecycles, error) {
+	return loadLifecycles(files, environ, flagLifecycles, func(string, string) error { return nil })
+}
+
+// LoadStrictLifecycles is LoadLifecycles with the checks of
+// StrictLifecycleMap applied to every lifecycle from every source: the name
+// must be one of known, or of KnownLifecycles when none are given, and the
+// path must pass ValidateLifecyclePath.
+func LoadStrictLifecycles(files []string, environ []string, flagLifecycles LifecycleMap, known ...string) (LoadedLifecycles, error) {
+	strict := NewStrictLifecycleMap(known...)
+
+	return loadLifecycles(files, environ, flagLifecycles, func(name, lifecyclePath string) error {
+		if !strict.known(name) {
+			return ErrLifecycleNameUnknown
+		}
+		return ValidateLifecyclePath(lifecyclePath)
+	})
+}
+
+func loadLifecycles(files []string, environ []string, flagLifecycles LifecycleMap, validate func(name, lifecyclePath string) error) (LoadedLifecycles, error) {
+	loaded := LoadedLifecycles{Lifecycles: LifecycleMap{}, Sources: map[string]string{}}
+
+	for _, file := range files {
+		lifecycles, err := LoadLifecycleFile(file)
+		if err != nil {
+			return LoadedLifecycles{}, err
+		}
+		err = loaded.add(lifecycles, validate, func(string) string { return "file " + file })
+		if err != nil {
+			return LoadedLifecycles{}, err
+		}
+	}
+
+	envLifecycles, envSources, err := lifecyclesFromEnvironment(environ)
+	if err != nil {
+		return LoadedLifecycles{}, err
+	}
+	err = loaded.add(envLifecycles, validate, func(name string) string { return envSources[name] })
+	if err != nil {
+		return LoadedLifecycles{}, err
+	}
+
+	err = loaded.add(flagLifecycles, validate, func(string) string { return LifecycleSourceFlag })
+	if err != nil {
+		return LoadedLifecycles{}, err
+	}
+	return loaded, nil
+}
+
+// LoadLifecycleFile reads a JSON or YAML object mapping lifecycle names to
+// paths, choosing the format by the file extension.
+func LoadLifecycleFile(file string) (LifecycleMap, error) {
+	payload, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycles := LifecycleMap{}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.Unmarshal(payload, &lifecycles)
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(payload, &lifecycles)
+	default:
+		return nil, fmt.Errorf("Invalid lifecycle file %s: expected a .json, .yml or .yaml extension", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid lifecycle file %s: %s", file, err)
+	}
+
+	for name, lifecyclePath := range lifecycles {
+		_, _, err = parseLifecycle(name + ":" + lifecyclePath)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid lifecycle file %s: %s", file, err)
+		}
+	}
+
+	// decoding into a map keeps only the last of repeated keys
+	names, err := lifecycleFileNames(file, payload)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid lifecycle file %s: %s", file, err)
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("Invalid lifecycle file %s: %s: %s", file, name, ErrLifecycleDuplicate)
+		}
+		seen[name] = true
+	}
+	return lifecycles, nil
+}
+
+// lifecycleFileNames returns the keys of a lifecycle file in order,
+// including repeated ones.
+func lifecycleFileNames(file string, payload []byte) ([]string, error) {
+	names := []string{}
+
+	if strings.ToLower(filepath.Ext(file)) != ".json" {
+		var items yaml.MapSlice
+		err := yaml.Unmarshal(payload, &items)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			names = append(names, fmt.Sprint(item.Key))
+		}
+		return names, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('{') {
+		return names, err
+	}
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, token.(string))
+
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// Describe lists the lifecycles by name, with where each came from.
+func (l LoadedLifecycles) Describe() []string {
+	names := make([]string, 0, len(l.Lifecycles))
+	for name := range l.Lifecycles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %s (from %s)", name, l.Lifecycles[name], l.Sources[name]))
+	}
+	return lines
+}
+
+func (l LoadedLifecycles) add(lifecycles LifecycleMap, validate func(name, lifecyclePath string) error, source func(name string) string) error {
+	names := make([]string, 0, len(lifecycles))
+	for name := range lifecycles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		lifecyclePath := lifecycles[name]
+		err := validate(name, lifecyclePath)
+		if err != nil {
+			return fmt.Errorf("Invalid lifecycle %s from %s: %s", name, source(name), err)
+		}
+
+		l.Lifecycles[name] = lifecyclePath
+		l.Sources[name] = source(name)
+	}
+	return nil
+}
+
+// lifecyclesFromEnvironment reads the LIFECYCLE_* variables in variable name
+// order, rejecting two variables that name the same lifecycle.
+func lifecyclesFromEnvironment(environ []string) (LifecycleMap, map[string]string, error) {
+	variables := map[string]string{}
+	for _, entry := range environ {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) == 2 && strings.HasPrefix(parts[0], LifecycleEnvPrefix) {
+			variables[parts[0]] = parts[1]
+		}
+	}
+
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lifecycles := LifecycleMap{}
+	sources := map[string]string{}
+	variableNames := map[string]string{}
+	for _, key := range keys {
+		name, lifecyclePath, err := parseLifecycle(variables[key])
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid environment variable %s: %s", key, err)
+		}
+		if other, found := variableNames[name]; found {
+			return nil, nil, fmt.Errorf("Invalid environment variables %s and %s: %s", other, key, ErrLifecycleDuplicate)
+		}
+		variableNames[name] = key
+		lifecycles[name] = lifecyclePath
+		sources[name] = "environment variable " + key
+	}
+	return lifecycles, sources, nil
+}
@@ -0,0 +1,176 @@
+package flags_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages/flags"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LifecycleSources", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "lifecycles")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(file, []byte(content), 0644)).To(Succeed())
+		return file
+	}
+
+	Describe("LoadLifecycleFile", func() {
+		It("loads JSON", func() {
+			file := writeFile("lifecycles.json", `{"docker": "docker_app_lifecycle.tgz"}`)
+
+			lifecycles, err := flags.LoadLifecycleFile(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lifecycles).To(Equal(flags.LifecycleMap{"docker": "docker_app_lifecycle.tgz"}))
+		})
+
+		It("loads YAML", func() {
+			file := writeFile("lifecycles.yml", "buildpack/cflinuxfs2: buildpack_app_lifecycle.tgz\ndocker: docker_app_lifecycle.tgz\n")
+
+			lifecycles, err := flags.LoadLifecycleFile(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lifecycles).To(Equal(flags.LifecycleMap{
+				"buildpack/cflinuxfs2": "buildpack_app_lifecycle.tgz",
+				"docker":               "docker_app_lifecycle.tgz",
+			}))
+		})
+
+		It("errors on unknown extensions", func() {
+			file := writeFile("lifecycles.txt", `docker: docker_app_lifecycle.tgz`)
+
+			_, err := flags.LoadLifecycleFile(file)
+			Expect(err).To(MatchError(ContainSubstring("expected a .json, .yml or .yaml extension")))
+		})
+
+		It("errors on malformed files", func() {
+			file := writeFile("lifecycles.json", `["docker"]`)
+
+			_, err := flags.LoadLifecycleFile(file)
+			Expect(err).To(MatchError(ContainSubstring(file)))
+		})
+
+		It("errors on lifecycles given twice", func() {
+			file := writeFile("lifecycles.json", `{"docker": "a.tgz", "docker": "b.tgz"}`)
+
+			_, err := flags.LoadLifecycleFile(file)
+			Expect(err).To(MatchError("Invalid lifecycle file " + file + ": docker: " + flags.ErrLifecycleDuplicate.Error()))
+
+			file = writeFile("lifecycles.yml", "docker: a.tgz\nbuildpack/cflinuxfs2: b.tgz\ndocker: c.tgz\n")
+
+			_, err = flags.LoadLifecycleFile(file)
+			Expect(err).To(MatchError("Invalid lifecycle file " + file + ": docker: " + flags.ErrLifecycleDuplicate.Error()))
+		})
+
+		It("errors on empty paths", func() {
+			file := writeFile("lifecycles.yaml", `docker: ""`)
+
+			_, err := flags.LoadLifecycleFile(file)
+			Expect(err).To(MatchError(ContainSubstring(flags.ErrLifecyclePathEmpty.Error())))
+		})
+	})
+
+	Describe("LoadLifecycles", func() {
+		var files []string
+
+		BeforeEach(func() {
+			files = []string{
+				writeFile("base.yml", "buildpack/cflinuxfs2: base/buildpack.tgz\ndocker: base/docker.tgz\nwindows: base/windows.tgz\n"),
+				writeFile("override.json", `{"docker": "override/docker.tgz"}`),
+			}
+		})
+
+		It("lets later files, then the environment, then flags win", func() {
+			environ := []string{
+				"PATH=/usr/bin",
+				"LIFECYCLE_CFLINUXFS2=buildpack/cflinuxfs2:env/buildpack.tgz",
+				"LIFECYCLE_WINDOWS=windows:env/windows.tgz",
+			}
+			flagLifecycles := flags.LifecycleMap{"windows": "flag/windows.tgz"}
+
+			loaded, err := flags.LoadLifecycles(files, environ, flagLifecycles)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(loaded.Lifecycles).To(Equal(flags.LifecycleMap{
+				"buildpack/cflinuxfs2": "env/buildpack.tgz",
+				"docker":               "override/docker.tgz",
+				"windows":              "flag/windows.tgz",
+			}))
+			Expect(loaded.Describe()).To(Equal([]string{
+				"buildpack/cflinuxfs2: env/buildpack.tgz (from environment variable LIFECYCLE_CFLINUXFS2)",
+				"docker: override/docker.tgz (from file " + files[1] + ")",
+				"windows: flag/windows.tgz (from flag)",
+			}))
+		})
+
+		It("errors on environment variables naming the same lifecycle", func() {
+			environ := []string{
+				"LIFECYCLE_B=docker:b.tgz",
+				"LIFECYCLE_A=docker:a.tgz",
+			}
+
+			_, err := flags.LoadLifecycles(nil, environ, nil)
+			Expect(err).To(MatchError("Invalid environment variables LIFECYCLE_A and LIFECYCLE_B: " + flags.ErrLifecycleDuplicate.Error()))
+		})
+
+		It("errors on malformed environment variables", func() {
+			_, err := flags.LoadLifecycles(nil, []string{"LIFECYCLE_DOCKER=docker.tgz"}, nil)
+			Expect(err).To(MatchError("Invalid environment variable LIFECYCLE_DOCKER: " + flags.ErrLifecycleFormatInvalid.Error()))
+		})
+
+		It("errors on missing files", func() {
+			_, err := flags.LoadLifecycles([]string{filepath.Join(dir, "missing.yml")}, nil, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("LoadStrictLifecycles", func() {
+		It("accepts known lifecycles with valid paths", func() {
+			file := writeFile("lifecycles.yml", "docker: docker_app_lifecycle/docker_app_lifecycle.tgz\n")
+			environ := []string{"LIFECYCLE_CFLINUXFS2=buildpack/cflinuxfs2:buildpack_app_lifecycle.tgz"}
+
+			loaded, err := flags.LoadStrictLifecycles([]string{file}, environ, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.Lifecycles).To(Equal(flags.LifecycleMap{
+				"buildpack/cflinuxfs2": "buildpack_app_lifecycle.tgz",
+				"docker":               "docker_app_lifecycle/docker_app_lifecycle.tgz",
+			}))
+		})
+
+		It("rejects unknown lifecycles from any source", func() {
+			file := writeFile("lifecycles.yml", "windows: windows_app_lifecycle.tgz\n")
+
+			_, err := flags.LoadStrictLifecycles([]string{file}, nil, nil)
+			Expect(err).To(MatchError("Invalid lifecycle windows from file " + file + ": " + flags.ErrLifecycleNameUnknown.Error()))
+
+			_, err = flags.LoadStrictLifecycles(nil, []string{"LIFECYCLE_WINDOWS=windows:windows_app_lifecycle.tgz"}, nil)
+			Expect(err).To(MatchError("Invalid lifecycle windows from environment variable LIFECYCLE_WINDOWS: " + flags.ErrLifecycleNameUnknown.Error()))
+
+			loaded, err := flags.LoadStrictLifecycles([]string{file}, nil, nil, "windows")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.Lifecycles).To(Equal(flags.LifecycleMap{"windows": "windows_app_lifecycle.tgz"}))
+		})
+
+		It("rejects invalid paths", func() {
+			_, err := flags.LoadStrictLifecycles(nil, nil, flags.LifecycleMap{"docker": "../docker.tgz"})
+			Expect(err).To(MatchError("Invalid lifecycle docker from flag: " + flags.ErrLifecyclePathTraversal.Error()))
+
+			_, err = flags.LoadStrictLifecycles(nil, []string{"LIFECYCLE_DOCKER=docker:/abs/docker.tgz"}, nil)
+			Expect(err).To(MatchError("Invalid lifecycle docker from environment variable LIFECYCLE_DOCKER: " + flags.ErrLifecyclePathInvalid.Error()))
+		})
+	})
+})
@@ -2,6 +2,8 @@ package flags
 
 import (
 	"fmt"
+	"path"
+	"regexp"
 	"strings"
 )
 
@@ -9,6 +11,10 @@ var (
 	ErrLifecycleFormatInvalid = newLifecycleError("not of the form 'lifecycle-name:path'")
 	ErrLifecycleNameEmpty     = newLifecycleError("empty lifecycle name")
 	ErrLifecyclePathEmpty     = newLifecycleError("empty path")
+	ErrLifecycleNameUnknown   = newLifecycleError("unknown lifecycle name")
+	ErrLifecyclePathInvalid   = newLifecycleError("path is not a relative path or blobstore key")
+	ErrLifecyclePathTraversal = newLifecycleError("path must not contain '..'")
+	ErrLifecycleDuplicate     = newLifecycleError("lifecycle specified more than once")
 )
 
 type lifecycleError struct {
@@ -30,19 +36,103 @@ func (s *LifecycleMap) String() string {
 }
 
 func (s *LifecycleMap) Set(value string) error {
+	name, lifecyclePath, err := parseLifecycle(value)
+	if err != nil {
+		return err
+	}
+
+	(*s)[name] = lifecyclePath
+	return nil
+}
+
+func parseLifecycle(value string) (string, string, error) {
 	parts := strings.SplitN(value, ":", 2)
 	if len(parts) != 2 {
-		return ErrLifecycleFormatInvalid
+		return "", "", ErrLifecycleFormatInvalid
 	}
 
 	if parts[0] == "" {
-		return ErrLifecycleNameEmpty
+		return "", "", ErrLifecycleNameEmpty
 	}
 
 	if parts[1] == "" {
-		return ErrLifecyclePathEmpty
+		return "", "", ErrLifecyclePathEmpty
 	}
 
-	(*s)[parts[0]] = parts[1]
+	return parts[0], parts[1], nil
+}
+
+// KnownLifecycles are the lifecycles StrictLifecycleMap accepts by default.
+var KnownLifecycles = []string{"buildpack/cflinuxfs2", "docker"}
+
+var lifecyclePathPattern = regexp.MustCompile(`^[A-Za-z0-9._\-/]+$`)
+
+// StrictLifecycleMap is a LifecycleMap flag that also rejects unknown
+// lifecycle names, paths that are not relative paths or blobstore keys, and
+// lifecycles given more than once.
+type StrictLifecycleMap struct {
+	Lifecycles      LifecycleMap
+	KnownLifecycles []string
+}
+
+// NewStrictLifecycleMap accepts the given lifecycle names, or
+// KnownLifecycles when none are given.
+func NewStrictLifecycleMap(known ...string) *StrictLifecycleMap {
+	if len(known) == 0 {
+		known = KnownLifecycles
+	}
+	return &StrictLifecycleMap{Lifecycles: LifecycleMap{}, KnownLifecycles: known}
+}
+
+func (s *StrictLifecycleMap) String() string {
+	return s.Lifecycles.String()
+}
+
+func (s *StrictLifecycleMap) Set(value string) error {
+	name, lifecyclePath, err := parseLifecycle(value)
+	if err != nil {
+		return err
+	}
+
+	if !s.known(name) {
+		return ErrLifecycleNameUnknown
+	}
+
+	err = ValidateLifecyclePath(lifecyclePath)
+	if err != nil {
+		return err
+	}
+
+	if _, found := s.Lifecycles[name]; found {
+		return ErrLifecycleDuplicate
+	}
+
+	s.Lifecycles[name] = lifecyclePath
+	return nil
+}
+
+func (s *StrictLifecycleMap) known(name string) bool {
+	for _, known := range s.KnownLifecycles {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateLifecyclePath checks that lifecyclePath is a clean relative path
+// or blobstore key, such as 'buildpack_app_lifecycle/buildpack_app_lifecycle.tgz'.
+func ValidateLifecyclePath(lifecyclePath string) error {
+	for _, segment := range strings.Split(lifecyclePath, "/") {
+		if segment == ".." {
+			return ErrLifecyclePathTraversal
+		}
+	}
+
+	if !lifecyclePathPattern.MatchString(lifecyclePath) ||
+		path.IsAbs(lifecyclePath) ||
+		path.Clean(lifecyclePath) != lifecyclePath {
+		return ErrLifecyclePathInvalid
+	}
 	return nil
 }
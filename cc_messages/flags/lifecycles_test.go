@@ -35,4 +35,68 @@ var _ = Describe("Lifecycles", func() {
 			Expect(err).To(Equal(flags.ErrLifecyclePathEmpty))
 		})
 	})
+
+	Describe("StrictLifecycleMap", func() {
+		var lifecycles *flags.StrictLifecycleMap
+		BeforeEach(func() {
+			lifecycles = flags.NewStrictLifecycleMap()
+		})
+
+		It("adds known lifecycles", func() {
+			Expect(lifecycles.Set("buildpack/cflinuxfs2:buildpack_app_lifecycle/buildpack_app_lifecycle.tgz")).To(Succeed())
+			Expect(lifecycles.Set("docker:docker_app_lifecycle.tgz")).To(Succeed())
+
+			Expect(lifecycles.Lifecycles).To(Equal(flags.LifecycleMap{
+				"buildpack/cflinuxfs2": "buildpack_app_lifecycle/buildpack_app_lifecycle.tgz",
+				"docker":               "docker_app_lifecycle.tgz",
+			}))
+		})
+
+		It("still checks the format", func() {
+			Expect(lifecycles.Set("blork")).To(Equal(flags.ErrLifecycleFormatInvalid))
+			Expect(lifecycles.Set("docker:")).To(Equal(flags.ErrLifecyclePathEmpty))
+		})
+
+		It("errors on unknown lifecycles", func() {
+			Expect(lifecycles.Set("windows:lifecycle.tgz")).To(Equal(flags.ErrLifecycleNameUnknown))
+		})
+
+		It("accepts the given lifecycles instead of the known ones", func() {
+			lifecycles = flags.NewStrictLifecycleMap("windows")
+			Expect(lifecycles.Set("windows:lifecycle.tgz")).To(Succeed())
+			Expect(lifecycles.Set("docker:lifecycle.tgz")).To(Equal(flags.ErrLifecycleNameUnknown))
+		})
+
+		It("errors on duplicates instead of overwriting", func() {
+			Expect(lifecycles.Set("docker:first.tgz")).To(Succeed())
+			Expect(lifecycles.Set("docker:second.tgz")).To(Equal(flags.ErrLifecycleDuplicate))
+			Expect(lifecycles.Lifecycles["docker"]).To(Equal("first.tgz"))
+		})
+	})
+
+	Describe("ValidateLifecyclePath", func() {
+		It("accepts relative paths and blobstore keys", func() {
+			Expect(flags.ValidateLifecyclePath("lifecycle.tgz")).To(Succeed())
+			Expect(flags.ValidateLifecyclePath("buildpack_app_lifecycle/buildpack-app_lifecycle.tgz")).To(Succeed())
+		})
+
+		It("rejects traversal", func() {
+			Expect(flags.ValidateLifecyclePath("../lifecycle.tgz")).To(Equal(flags.ErrLifecyclePathTraversal))
+			Expect(flags.ValidateLifecyclePath("a/../../lifecycle.tgz")).To(Equal(flags.ErrLifecyclePathTraversal))
+		})
+
+		It("rejects paths that are not clean and relative", func() {
+			for _, path := range []string{
+				"/var/vcap/lifecycle.tgz",
+				"http://example.com/lifecycle.tgz",
+				"a//lifecycle.tgz",
+				"./lifecycle.tgz",
+				"lifecycles/",
+				"life cycle.tgz",
+				`lifecycles\lifecycle.tgz`,
+			} {
+				Expect(flags.ValidateLifecyclePath(path)).To(Equal(flags.ErrLifecyclePathInvalid), path)
+			}
+		})
+	})
 })
@@ -0,0 +1,144 @@
+package cc_messages
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+type RequestKind string
+
+const (
+	DesireAppRequest RequestKind = "desire_app"
+	StagingRequest   RequestKind = "staging"
+	TaskRequest      RequestKind = "task"
+)
+
+const (
+	EnvironmentPayload = "environment"
+	RoutingInfoPayload = "routing_info"
+)
+
+const (
+	HTTPRoutesConversion      = "http_routes"
+	TCPRoutesConversion       = "tcp_routes"
+	StagingResultConversion   = "staging_result"
+	StagingResponseConversion = "staging_response"
+)
+
+// Instrumentation is told about the requests components observe, the
+// failures of the request validators and the conversions this package
+// performs. Nothing is recorded until one is set; the instrumentation
+// subpackage provides one that sends metrics.
+type Instrumentation interface {
+	RequestReceived(kind RequestKind, lifecycle string)
+	ValidationFailed(kind RequestKind, errorID string)
+	PayloadSize(kind RequestKind, payload string, bytes int)
+	ConversionDuration(conversion string, duration time.Duration)
+}
+
+var (
+	instrumentationLock sync.RWMutex
+	instrumentation     Instrumentation
+)
+
+// SetInstrumentation installs i, which may be nil to stop recording, and
+// returns the previous instrumentation.
+func SetInstrumentation(i Instrumentation) Instrumentation {
+	instrumentationLock.Lock()
+	defer instrumentationLock.Unlock()
+
+	previous := instrumentation
+	instrumentation = i
+	return previous
+}
+
+func currentInstrumentation() Instrumentation {
+	instrumentationLock.RLock()
+	defer instrumentationLock.RUnlock()
+
+	return instrumentation
+}
+
+// Observe reports the request and the sizes of its payloads to the
+// installed Instrumentation. It does not validate the request.
+func (r DesireAppRequestFromCC) Observe() {
+	lifecycle := "buildpack"
+	if r.DockerImageUrl != "" {
+		lifecycle = "docker"
+	}
+	observeRequest(DesireAppRequest, lifecycle, r.Environment, r.RoutingInfo)
+}
+
+// Observe reports the request and the size of its environment to the
+// installed Instrumentation. It does not validate the request.
+func (r StagingRequestFromCC) Observe() {
+	observeRequest(StagingRequest, r.Lifecycle, r.Environment, nil)
+}
+
+// Observe reports the request and the size of its environment to the
+// installed Instrumentation. It does not validate the request.
+func (r TaskRequestFromCC) Observe() {
+	observeRequest(TaskRequest, r.Lifecycle, r.EnvironmentVariables, nil)
+}
+
+func observeRequest(kind RequestKind, lifecycle string, env []*models.EnvironmentVariable, routingInfo CCRouteInfo) {
+	i := currentInstrumentation()
+	if i == nil {
+		return
+	}
+
+	i.RequestReceived(kind, lifecycle)
+
+	envSize := 0
+	if len(env) > 0 {
+		envSize = payloadSize(env)
+	}
+	i.PayloadSize(kind, EnvironmentPayload, envSize)
+
+	if routingInfo != nil {
+		routingInfoSize := 0
+		if len(routingInfo) > 0 {
+			routingInfoSize = payloadSize(routingInfo)
+		}
+		i.PayloadSize(kind, RoutingInfoPayload, routingInfoSize)
+	}
+}
+
+// observeValidation reports err, if any, as a validation failure of a kind
+// of request, and returns it.
+func observeValidation(kind RequestKind, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	i := currentInstrumentation()
+	if i != nil {
+		i.ValidationFailed(kind, ValidationErrorID(err))
+	}
+	return err
+}
+
+// timeConversion starts timing a conversion; call the result when it is
+// done.
+func timeConversion(conversion string) func() {
+	i := currentInstrumentation()
+	if i == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		i.ConversionDuration(conversion, time.Since(start))
+	}
+}
+
+func payloadSize(payload interface{}) int {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return 0
+	}
+	return len(encoded)
+}
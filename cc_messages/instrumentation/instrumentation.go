@@ -0,0 +1,124 @@
+// Package instrumentation reports the cc_messages instrumentation through
+// the metric package. It is kept apart from cc_messages so that only the
+// components that opt in depend on the metric senders.
+package instrumentation
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+)
+
+const (
+	RequestsMetric           = "CCMessagesRequests"
+	ValidationFailuresMetric = "CCMessagesValidationFailures"
+	EnvironmentSizeMetric    = "CCMessagesEnvironmentSize"
+	RoutingInfoSizeMetric    = "CCMessagesRoutingInfoSize"
+	ConversionDurationMetric = "CCMessagesConversionDuration"
+)
+
+// PayloadSizeBuckets are the upper bounds, in bytes, of the payload size
+// histograms.
+var PayloadSizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576}
+
+// Declarations lists the metrics a MetricInstrumentation sends, for a
+// metric.Catalogue. Declaring them is left to the caller.
+func Declarations() []metric.Declaration {
+	return []metric.Declaration{
+		{Name: RequestsMetric, Type: metric.CounterType, Help: "Desire, staging and task requests observed, tagged by kind and lifecycle"},
+		{Name: ValidationFailuresMetric, Type: metric.CounterType, Help: "Requests that failed validation, tagged by kind and error_id"},
+		{Name: EnvironmentSizeMetric, Type: metric.HistogramType, Unit: metric.UnitBytes, Help: "Size of the JSON encoded environment of the requests, tagged by kind"},
+		{Name: RoutingInfoSizeMetric, Type: metric.HistogramType, Unit: metric.UnitBytes, Help: "Size of the JSON encoded routing info of the desire requests"},
+		{Name: ConversionDurationMetric, Type: metric.ValueType, Unit: metric.UnitNanos, Help: "Time taken by a conversion, tagged by conversion"},
+	}
+}
+
+// MetricInstrumentation is a cc_messages.Instrumentation that sends through
+// a metric.Sender. Counters and durations are sent as they happen; payload
+// sizes are observed into histograms, which are sent by Emit.
+type MetricInstrumentation struct {
+	requests           metric.TaggedCounter
+	validationFailures metric.TaggedCounter
+	conversionDuration metric.TaggedDuration
+	environmentSize    *metric.Histogram
+	routingInfoSize    *metric.Histogram
+
+	lock  sync.Mutex
+	sizes map[string]*metric.Histogram
+}
+
+// NewMetricInstrumentation sends through sender, or through the metric
+// package's sender when it is nil. Install the result with
+// cc_messages.SetInstrumentation.
+func NewMetricInstrumentation(sender metric.Sender) *MetricInstrumentation {
+	return &MetricInstrumentation{
+		requests:           metric.Counter(RequestsMetric).WithSender(sender),
+		validationFailures: metric.Counter(ValidationFailuresMetric).WithSender(sender),
+		conversionDuration: metric.Duration(ConversionDurationMetric).WithSender(sender),
+		environmentSize:    metric.NewHistogram(EnvironmentSizeMetric, metric.UnitBytes, PayloadSizeBuckets).WithSender(sender),
+		routingInfoSize:    metric.NewHistogram(RoutingInfoSizeMetric, metric.UnitBytes, PayloadSizeBuckets).WithSender(sender),
+		sizes:              map[string]*metric.Histogram{},
+	}
+}
+
+func (m *MetricInstrumentation) RequestReceived(kind cc_messages.RequestKind, lifecycle string) {
+	m.requests.WithTags(map[string]string{"kind": string(kind), "lifecycle": lifecycle}).Increment()
+}
+
+func (m *MetricInstrumentation) ValidationFailed(kind cc_messages.RequestKind, errorID string) {
+	m.validationFailures.WithTags(map[string]string{"kind": string(kind), "error_id": errorID}).Increment()
+}
+
+func (m *MetricInstrumentation) PayloadSize(kind cc_messages.RequestKind, payload string, bytes int) {
+	m.sizeHistogram(kind, payload).Observe(float64(bytes))
+}
+
+func (m *MetricInstrumentation) ConversionDuration(conversion string, duration time.Duration) {
+	m.conversionDuration.WithTags(map[string]string{"conversion": conversion}).Send(duration)
+}
+
+// Emit sends the payload sizes observed since the previous Emit, and returns
+// the first error. Call it periodically.
+func (m *MetricInstrumentation) Emit() error {
+	m.lock.Lock()
+	keys := make([]string, 0, len(m.sizes))
+	for key := range m.sizes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	histograms := make([]*metric.Histogram, 0, len(keys))
+	for _, key := range keys {
+		histograms = append(histograms, m.sizes[key])
+	}
+	m.lock.Unlock()
+
+	var firstErr error
+	for _, histogram := range histograms {
+		err := histogram.Emit()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m *MetricInstrumentation) sizeHistogram(kind cc_messages.RequestKind, payload string) *metric.Histogram {
+	key := string(kind) + "/" + payload
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	histogram, ok := m.sizes[key]
+	if !ok {
+		size := m.environmentSize
+		if payload == cc_messages.RoutingInfoPayload {
+			size = m.routingInfoSize
+		}
+		histogram = size.WithTags(map[string]string{"kind": string(kind)})
+		m.sizes[key] = histogram
+	}
+	return histogram
+}
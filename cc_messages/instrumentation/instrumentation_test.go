@@ -0,0 +1,116 @@
+package instrumentation_test
+
+import (
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages/instrumentation"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric/metrictest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MetricInstrumentation", func() {
+	var (
+		sender   *metrictest.FakeSender
+		metrics  *instrumentation.MetricInstrumentation
+		previous cc_messages.Instrumentation
+	)
+
+	BeforeEach(func() {
+		sender = metrictest.NewFakeSender()
+		metrics = instrumentation.NewMetricInstrumentation(sender)
+		previous = cc_messages.SetInstrumentation(metrics)
+	})
+
+	AfterEach(func() {
+		cc_messages.SetInstrumentation(previous)
+	})
+
+	It("declares its metrics without conflicts", func() {
+		catalogue := metric.NewCatalogue()
+		for _, declaration := range instrumentation.Declarations() {
+			catalogue.Declare(declaration)
+		}
+
+		Expect(catalogue.Declarations()).To(HaveLen(5))
+		Expect(catalogue.Validate()).To(Succeed())
+	})
+
+	It("counts requests and validation failures with their tags", func() {
+		desire := cc_messages.DesireAppRequestFromCC{
+			DockerCredentials: cc_messages.DockerCredentials{DockerUser: "user"},
+		}
+		desire.Observe()
+		Expect(desire.ValidateDockerCredentials()).To(HaveOccurred())
+
+		Expect(sender.Events()).To(ContainElement(metrictest.Event{
+			Type:  metrictest.IncrementCounterEvent,
+			Name:  instrumentation.RequestsMetric,
+			Value: 1,
+			Tags:  map[string]string{"kind": "desire_app", "lifecycle": "buildpack"},
+		}))
+		Expect(sender.Events()).To(ContainElement(metrictest.Event{
+			Type:  metrictest.IncrementCounterEvent,
+			Name:  instrumentation.ValidationFailuresMetric,
+			Value: 1,
+			Tags:  map[string]string{"kind": "desire_app", "error_id": "DockerCredentialsIncomplete"},
+		}))
+	})
+
+	It("sends conversion durations", func() {
+		_, err := cc_messages.CCTCPRoutes{{RouterGroupGuid: "guid"}}.CCRouteInfo()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sender).To(metrictest.HaveSentDuration(instrumentation.ConversionDurationMetric))
+	})
+
+	It("observes payload sizes in byte histograms, sent on Emit", func() {
+		routingInfo, err := cc_messages.CCHTTPRoutes{{Hostname: "foo.example.com"}}.CCRouteInfo()
+		Expect(err).NotTo(HaveOccurred())
+
+		environment := []*models.EnvironmentVariable{{Name: "FOO", Value: "bar"}}
+		cc_messages.DesireAppRequestFromCC{Environment: environment, RoutingInfo: routingInfo}.Observe()
+		cc_messages.DesireAppRequestFromCC{Environment: environment, RoutingInfo: routingInfo}.Observe()
+		cc_messages.TaskRequestFromCC{Lifecycle: "docker"}.Observe()
+		Expect(sender.EventsNamed(instrumentation.EnvironmentSizeMetric + ".count")).To(BeEmpty())
+
+		Expect(metrics.Emit()).To(Succeed())
+
+		environmentSize := float64(len(`[{"name":"FOO","value":"bar"}]`))
+		routingInfoSize := float64(len(`{"http_routes":[{"hostname":"foo.example.com"}]}`))
+		Expect(sender.Events()).To(ContainElement(metrictest.Event{
+			Type:  metrictest.SendValueEvent,
+			Name:  instrumentation.EnvironmentSizeMetric + ".count",
+			Value: 2,
+			Unit:  "Metric",
+			Tags:  map[string]string{"kind": "desire_app"},
+		}))
+		Expect(sender.Events()).To(ContainElement(metrictest.Event{
+			Type:  metrictest.SendValueEvent,
+			Name:  instrumentation.EnvironmentSizeMetric + ".max",
+			Value: environmentSize,
+			Unit:  "B",
+			Tags:  map[string]string{"kind": "desire_app"},
+		}))
+		Expect(sender.Events()).To(ContainElement(metrictest.Event{
+			Type:  metrictest.SendValueEvent,
+			Name:  instrumentation.EnvironmentSizeMetric + ".max",
+			Value: 0,
+			Unit:  "B",
+			Tags:  map[string]string{"kind": "task"},
+		}))
+		Expect(sender.Events()).To(ContainElement(metrictest.Event{
+			Type:  metrictest.SendValueEvent,
+			Name:  instrumentation.RoutingInfoSizeMetric + ".max",
+			Value: routingInfoSize,
+			Unit:  "B",
+			Tags:  map[string]string{"kind": "desire_app"},
+		}))
+
+		sender.Reset()
+		Expect(metrics.Emit()).To(Succeed())
+		Expect(sender.Events()).To(BeEmpty())
+	})
+})
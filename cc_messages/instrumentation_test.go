@@ -0,0 +1,163 @@
+package cc_messages_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type recordingInstrumentation struct {
+	requests    []string
+	failures    []string
+	sizes       map[string]int
+	conversions []string
+}
+
+func (i *recordingInstrumentation) RequestReceived(kind cc_messages.RequestKind, lifecycle string) {
+	i.requests = append(i.requests, string(kind)+"/"+lifecycle)
+}
+
+func (i *recordingInstrumentation) ValidationFailed(kind cc_messages.RequestKind, errorID string) {
+	i.failures = append(i.failures, string(kind)+"/"+errorID)
+}
+
+func (i *recordingInstrumentation) PayloadSize(kind cc_messages.RequestKind, payload string, bytes int) {
+	i.sizes[string(kind)+"/"+payload] = bytes
+}
+
+func (i *recordingInstrumentation) ConversionDuration(conversion string, duration time.Duration) {
+	i.conversions = append(i.conversions, conversion)
+}
+
+var _ = Describe("Instrumentation", func() {
+	var (
+		instrumentation *recordingInstrumentation
+		previous        cc_messages.Instrumentation
+	)
+
+	BeforeEach(func() {
+		instrumentation = &recordingInstrumentation{sizes: map[string]int{}}
+		previous = cc_messages.SetInstrumentation(instrumentation)
+	})
+
+	AfterEach(func() {
+		cc_messages.SetInstrumentation(previous)
+	})
+
+	It("is not installed by default", func() {
+		Expect(previous).To(BeNil())
+	})
+
+	It("records observed requests by lifecycle, with their payload sizes", func() {
+		routingInfo, err := cc_messages.CCHTTPRoutes{{Hostname: "foo.example.com"}}.CCRouteInfo()
+		Expect(err).NotTo(HaveOccurred())
+
+		cc_messages.DesireAppRequestFromCC{
+			DockerImageUrl: "docker:///cloudfoundry/grace",
+			Environment:    []*models.EnvironmentVariable{{Name: "FOO", Value: "bar"}},
+			RoutingInfo:    routingInfo,
+		}.Observe()
+		cc_messages.StagingRequestFromCC{Lifecycle: "buildpack"}.Observe()
+		cc_messages.TaskRequestFromCC{Lifecycle: "docker", EnvironmentVariables: []*models.EnvironmentVariable{}}.Observe()
+
+		Expect(instrumentation.requests).To(Equal([]string{"desire_app/docker", "staging/buildpack", "task/docker"}))
+		Expect(instrumentation.sizes).To(Equal(map[string]int{
+			"desire_app/environment":  len(`[{"name":"FOO","value":"bar"}]`),
+			"desire_app/routing_info": len(`{"http_routes":[{"hostname":"foo.example.com"}]}`),
+			"staging/environment":     0,
+			"task/environment":        0,
+		}))
+		Expect(instrumentation.failures).To(BeEmpty())
+	})
+
+	It("records the failures of the request validators by error id", func() {
+		desire := cc_messages.DesireAppRequestFromCC{
+			PlacementConstraints: cc_messages.PlacementConstraints{RequiredPlacementTags: []string{""}},
+			Sidecars:             []cc_messages.Sidecar{{Name: "envoy"}},
+		}
+		Expect(desire.ValidatePlacementConstraints()).To(Equal(cc_messages.ErrPlacementTagEmpty))
+		Expect(desire.ValidateSidecars()).To(Equal(cc_messages.ErrSidecarCommandEmpty))
+		Expect(desire.ValidateVolumeMounts()).To(Succeed())
+
+		staging := cc_messages.StagingRequestFromCC{
+			PlacementConstraints: cc_messages.PlacementConstraints{RequiredPlacementTags: []string{"pci", "pci"}},
+		}
+		Expect(staging.ValidatePlacementConstraints()).To(Equal(cc_messages.ErrPlacementTagDuplicate))
+
+		task := cc_messages.TaskRequestFromCC{VolumeMounts: []*models.VolumeMount{nil}}
+		Expect(task.ValidateVolumeMounts()).To(HaveOccurred())
+
+		Expect(instrumentation.failures).To(Equal([]string{
+			"desire_app/PlacementTagEmpty",
+			"desire_app/SidecarCommandEmpty",
+			"staging/PlacementTagDuplicate",
+			"task/InvalidVolumeMount",
+		}))
+		Expect(instrumentation.requests).To(BeEmpty())
+	})
+
+	It("records the failures of the environment, egress rule, deployment strategy and docker staging validators", func() {
+		invalidEnvironment := []*models.EnvironmentVariable{{Name: "1FOO", Value: "bar"}}
+		invalidEgressRules := []*models.SecurityGroupRule{nil}
+
+		desire := cc_messages.DesireAppRequestFromCC{
+			Environment:        invalidEnvironment,
+			EgressRules:        invalidEgressRules,
+			DeploymentStrategy: &cc_messages.DeploymentStrategy{},
+		}
+		Expect(desire.ValidateEnvironment()).To(HaveOccurred())
+		Expect(desire.ValidateEgressRules()).To(HaveOccurred())
+		Expect(desire.ValidateDeploymentStrategy()).To(Equal(cc_messages.ErrDeploymentStrategyStalled))
+		Expect(cc_messages.DesireAppRequestFromCC{}.ValidateDeploymentStrategy()).To(Succeed())
+
+		staging := cc_messages.StagingRequestFromCC{Environment: invalidEnvironment, EgressRules: invalidEgressRules}
+		Expect(staging.ValidateEnvironment()).To(HaveOccurred())
+		Expect(staging.ValidateEgressRules()).To(HaveOccurred())
+
+		dockerStagingData := cc_messages.DockerStagingData{
+			DockerCredentials: cc_messages.DockerCredentials{DockerUser: "user", DockerToken: "token"},
+		}
+		Expect(dockerStagingData.ValidateDockerCredentials()).To(HaveOccurred())
+
+		task := cc_messages.TaskRequestFromCC{EnvironmentVariables: invalidEnvironment, EgressRules: invalidEgressRules}
+		Expect(task.ValidateEnvironment()).To(HaveOccurred())
+		Expect(task.ValidateEgressRules()).To(HaveOccurred())
+
+		Expect(instrumentation.failures).To(Equal([]string{
+			"desire_app/InvalidEnvironmentVariableName",
+			"desire_app/InvalidEgressRule",
+			"desire_app/DeploymentStrategyStalled",
+			"staging/InvalidEnvironmentVariableName",
+			"staging/InvalidEgressRule",
+			"staging/DockerCredentialsIncomplete",
+			"task/InvalidEnvironmentVariableName",
+			"task/InvalidEgressRule",
+		}))
+		Expect(instrumentation.requests).To(BeEmpty())
+	})
+
+	It("times conversions", func() {
+		_, err := cc_messages.CCTCPRoutes{{RouterGroupGuid: "guid"}}.CCRouteInfo()
+		Expect(err).NotTo(HaveOccurred())
+
+		response, err := cc_messages.NewStagingResponseForCC(cc_messages.StagingResult{LifecycleType: "buildpack"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = response.StagingResult()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(instrumentation.conversions).To(Equal([]string{"tcp_routes", "staging_response", "staging_result"}))
+	})
+
+	Describe("ValidationErrorID", func() {
+		It("identifies the validation errors", func() {
+			Expect(cc_messages.ValidationErrorID(cc_messages.ErrDockerCredentialsConflict)).To(Equal("DockerCredentialsConflict"))
+			Expect(cc_messages.ValidationErrorID(cc_messages.EgressRuleError{})).To(Equal("InvalidEgressRule"))
+			Expect(cc_messages.ValidationErrorID(cc_messages.VolumeMountError{})).To(Equal("InvalidVolumeMount"))
+			Expect(cc_messages.ValidationErrorID(cc_messages.ErrDockerImageEmpty)).To(Equal("ValidationError"))
+		})
+	})
+})
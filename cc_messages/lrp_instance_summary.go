@@ -0,0 +1,98 @@
+package cc_messages
+
+type LRPInstancesHealth string
+
+const (
+	LRPInstancesHealthy  LRPInstancesHealth = "healthy"
+	LRPInstancesDegraded LRPInstancesHealth = "degraded"
+	LRPInstancesDown     LRPInstancesHealth = "down"
+)
+
+// LRPInstanceSummary aggregates the instances of a single process. Since and
+// stats only consider instances that report them; the stats are nil when no
+// instance does.
+type LRPInstanceSummary struct {
+	StateCounts      map[LRPInstanceState]int
+	RunningInstances int
+	OldestSince      int64
+	NewestSince      int64
+	AverageStats     *LRPInstanceStats
+	MaxStats         *LRPInstanceStats
+	Health           LRPInstancesHealth
+}
+
+// SummarizeLRPInstances aggregates the instances against the desired number
+// of instances. An index counts as running once, however many RUNNING
+// instances report it, and indexes at or above numInstances are ignored for
+// the health verdict.
+func SummarizeLRPInstances(instances []LRPInstance, numInstances int) LRPInstanceSummary {
+	summary := LRPInstanceSummary{StateCounts: map[LRPInstanceState]int{}}
+	runningIndexes := map[uint]bool{}
+	statsCount := 0
+	var cpuTotal float64
+	var memoryTotal, diskTotal uint64
+
+	for _, instance := range instances {
+		summary.StateCounts[instance.State]++
+
+		if instance.State == LRPInstanceStateRunning && int(instance.Index) < numInstances {
+			runningIndexes[instance.Index] = true
+		}
+
+		if instance.Since != 0 {
+			if summary.OldestSince == 0 || instance.Since < summary.OldestSince {
+				summary.OldestSince = instance.Since
+			}
+			if instance.Since > summary.NewestSince {
+				summary.NewestSince = instance.Since
+			}
+		}
+
+		stats := instance.Stats
+		if stats == nil {
+			continue
+		}
+
+		if summary.MaxStats == nil {
+			summary.MaxStats = &LRPInstanceStats{}
+		}
+		if stats.Time.After(summary.MaxStats.Time) {
+			summary.MaxStats.Time = stats.Time
+		}
+		if stats.CpuPercentage > summary.MaxStats.CpuPercentage {
+			summary.MaxStats.CpuPercentage = stats.CpuPercentage
+		}
+		if stats.MemoryBytes > summary.MaxStats.MemoryBytes {
+			summary.MaxStats.MemoryBytes = stats.MemoryBytes
+		}
+		if stats.DiskBytes > summary.MaxStats.DiskBytes {
+			summary.MaxStats.DiskBytes = stats.DiskBytes
+		}
+
+		statsCount++
+		cpuTotal += stats.CpuPercentage
+		memoryTotal += stats.MemoryBytes
+		diskTotal += stats.DiskBytes
+	}
+
+	if statsCount > 0 {
+		summary.AverageStats = &LRPInstanceStats{
+			Time:          summary.MaxStats.Time,
+			CpuPercentage: cpuTotal / float64(statsCount),
+			MemoryBytes:   memoryTotal / uint64(statsCount),
+			DiskBytes:     diskTotal / uint64(statsCount),
+		}
+	}
+
+	summary.RunningInstances = len(runningIndexes)
+	switch {
+	case summary.RunningInstances >= numInstances:
+		summary.Health = LRPInstancesHealthy
+	case summary.RunningInstances == 0:
+		summary.Health = LRPInstancesDown
+	default:
+		summary.Health = LRPInstancesDegraded
+	}
+
+	return summary
+}
@@ -0,0 +1,88 @@
+package cc_messages_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SummarizeLRPInstances", func() {
+	var (
+		now       time.Time
+		instances []cc_messages.LRPInstance
+	)
+
+	BeforeEach(func() {
+		now = time.Unix(1451606400, 0).UTC()
+		instances = []cc_messages.LRPInstance{
+			{
+				Index: 0, State: cc_messages.LRPInstanceStateRunning, Since: 100,
+				Stats: &cc_messages.LRPInstanceStats{Time: now, CpuPercentage: 0.5, MemoryBytes: 100, DiskBytes: 1000},
+			},
+			{
+				Index: 1, State: cc_messages.LRPInstanceStateRunning, Since: 300,
+				Stats: &cc_messages.LRPInstanceStats{Time: now.Add(time.Second), CpuPercentage: 0.1, MemoryBytes: 300, DiskBytes: 3000},
+			},
+			{Index: 2, State: cc_messages.LRPInstanceStateCrashed, Since: 200},
+		}
+	})
+
+	It("counts instances per state", func() {
+		summary := cc_messages.SummarizeLRPInstances(instances, 3)
+		Expect(summary.StateCounts).To(Equal(map[cc_messages.LRPInstanceState]int{
+			cc_messages.LRPInstanceStateRunning: 2,
+			cc_messages.LRPInstanceStateCrashed: 1,
+		}))
+		Expect(summary.RunningInstances).To(Equal(2))
+	})
+
+	It("finds the oldest and newest since", func() {
+		summary := cc_messages.SummarizeLRPInstances(instances, 3)
+		Expect(summary.OldestSince).To(Equal(int64(100)))
+		Expect(summary.NewestSince).To(Equal(int64(300)))
+	})
+
+	It("averages and maximises the stats", func() {
+		summary := cc_messages.SummarizeLRPInstances(instances, 3)
+		Expect(summary.AverageStats.CpuPercentage).To(BeNumerically("~", 0.3))
+		Expect(summary.AverageStats.MemoryBytes).To(Equal(uint64(200)))
+		Expect(summary.AverageStats.DiskBytes).To(Equal(uint64(2000)))
+		Expect(*summary.MaxStats).To(Equal(cc_messages.LRPInstanceStats{
+			Time: now.Add(time.Second), CpuPercentage: 0.5, MemoryBytes: 300, DiskBytes: 3000,
+		}))
+	})
+
+	It("has no stats when no instance reports them", func() {
+		summary := cc_messages.SummarizeLRPInstances(instances[2:], 3)
+		Expect(summary.AverageStats).To(BeNil())
+		Expect(summary.MaxStats).To(BeNil())
+	})
+
+	Describe("health", func() {
+		It("is healthy when every desired index is running", func() {
+			Expect(cc_messages.SummarizeLRPInstances(instances, 2).Health).To(Equal(cc_messages.LRPInstancesHealthy))
+			Expect(cc_messages.SummarizeLRPInstances(nil, 0).Health).To(Equal(cc_messages.LRPInstancesHealthy))
+		})
+
+		It("is degraded when some desired indexes are not running", func() {
+			Expect(cc_messages.SummarizeLRPInstances(instances, 3).Health).To(Equal(cc_messages.LRPInstancesDegraded))
+		})
+
+		It("is down when no desired index is running", func() {
+			Expect(cc_messages.SummarizeLRPInstances(instances[2:], 3).Health).To(Equal(cc_messages.LRPInstancesDown))
+		})
+
+		It("does not count duplicate or extra indexes", func() {
+			instances = append(instances,
+				cc_messages.LRPInstance{Index: 1, State: cc_messages.LRPInstanceStateRunning},
+				cc_messages.LRPInstance{Index: 5, State: cc_messages.LRPInstanceStateRunning},
+			)
+			summary := cc_messages.SummarizeLRPInstances(instances, 3)
+			Expect(summary.RunningInstances).To(Equal(2))
+			Expect(summary.Health).To(Equal(cc_messages.LRPInstancesDegraded))
+		})
+	})
+})
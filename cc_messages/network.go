@@ -0,0 +1,110 @@
+package cc_messages
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+const (
+	NetworkPolicyGroupIdKey    = "policy_group_id"
+	NetworkPortsKey            = "ports"
+	NetworkIsolationSegmentKey = "isolation_segment"
+)
+
+// CFNetwork is the typed view of the properties CC passes through in
+// DesireAppRequestFromCC.Network. Properties holds any other entries so that
+// converting back to a models.Network loses nothing.
+type CFNetwork struct {
+	PolicyGroupId    string
+	Ports            []uint32
+	IsolationSegment string
+	Properties       map[string]string
+}
+
+type InvalidNetworkPortsError struct {
+	Value string
+}
+
+func (e InvalidNetworkPortsError) Error() string {
+	return fmt.Sprintf("Invalid network ports: '%s' is not a comma-separated list of ports", e.Value)
+}
+
+func NewCFNetwork(network *models.Network) (CFNetwork, error) {
+	cfNetwork := CFNetwork{}
+	if network == nil {
+		return cfNetwork, nil
+	}
+
+	for key, value := range network.Properties {
+		switch key {
+		case NetworkPolicyGroupIdKey:
+			cfNetwork.PolicyGroupId = value
+		case NetworkIsolationSegmentKey:
+			cfNetwork.IsolationSegment = value
+		case NetworkPortsKey:
+			ports, err := parseNetworkPorts(value)
+			if err != nil {
+				return CFNetwork{}, err
+			}
+			cfNetwork.Ports = ports
+		default:
+			if cfNetwork.Properties == nil {
+				cfNetwork.Properties = map[string]string{}
+			}
+			cfNetwork.Properties[key] = value
+		}
+	}
+
+	return cfNetwork, nil
+}
+
+// Network converts back to the pass-through representation. An empty spec
+// converts to nil so that the request omits the network.
+func (n CFNetwork) Network() *models.Network {
+	properties := map[string]string{}
+	for key, value := range n.Properties {
+		properties[key] = value
+	}
+
+	if n.PolicyGroupId != "" {
+		properties[NetworkPolicyGroupIdKey] = n.PolicyGroupId
+	}
+	if n.IsolationSegment != "" {
+		properties[NetworkIsolationSegmentKey] = n.IsolationSegment
+	}
+	if len(n.Ports) > 0 {
+		ports := make([]string, len(n.Ports))
+		for i, port := range n.Ports {
+			ports[i] = strconv.FormatUint(uint64(port), 10)
+		}
+		properties[NetworkPortsKey] = strings.Join(ports, ",")
+	}
+
+	if len(properties) == 0 {
+		return nil
+	}
+	return &models.Network{Properties: properties}
+}
+
+func (r DesireAppRequestFromCC) CFNetwork() (CFNetwork, error) {
+	return NewCFNetwork(r.Network)
+}
+
+func parseNetworkPorts(value string) ([]uint32, error) {
+	ports := []uint32{}
+	if strings.TrimSpace(value) == "" {
+		return ports, nil
+	}
+
+	for _, field := range strings.Split(value, ",") {
+		port, err := strconv.ParseUint(strings.TrimSpace(field), 10, 16)
+		if err != nil || port == 0 {
+			return nil, InvalidNetworkPortsError{Value: value}
+		}
+		ports = append(ports, uint32(port))
+	}
+	return ports, nil
+}
@@ -0,0 +1,58 @@
+package cc_messages_test
+
+import (
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CFNetwork", func() {
+	It("reads the typed fields from the network properties", func() {
+		request := cc_messages.DesireAppRequestFromCC{
+			Network: &models.Network{Properties: map[string]string{
+				"policy_group_id":   "app-guid",
+				"ports":             "8080, 9090",
+				"isolation_segment": "regulated",
+				"space_id":          "space-guid",
+			}},
+		}
+
+		network, err := request.CFNetwork()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(network).To(Equal(cc_messages.CFNetwork{
+			PolicyGroupId:    "app-guid",
+			Ports:            []uint32{8080, 9090},
+			IsolationSegment: "regulated",
+			Properties:       map[string]string{"space_id": "space-guid"},
+		}))
+	})
+
+	It("converts back to the same network properties", func() {
+		original := &models.Network{Properties: map[string]string{
+			"policy_group_id":   "app-guid",
+			"ports":             "8080,9090",
+			"isolation_segment": "regulated",
+			"space_id":          "space-guid",
+		}}
+
+		network, err := cc_messages.NewCFNetwork(original)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(network.Network()).To(Equal(original))
+	})
+
+	It("converts an empty spec to no network", func() {
+		network, err := cc_messages.NewCFNetwork(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(network.Network()).To(BeNil())
+	})
+
+	It("errors when the ports are invalid", func() {
+		_, err := cc_messages.NewCFNetwork(&models.Network{Properties: map[string]string{"ports": "8080,http"}})
+		Expect(err).To(Equal(cc_messages.InvalidNetworkPortsError{Value: "8080,http"}))
+
+		_, err = cc_messages.NewCFNetwork(&models.Network{Properties: map[string]string{"ports": "70000"}})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,90 @@
+package cc_messages
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	ErrPlacementTagEmpty        = errors.New("Invalid placement constraints: empty placement tag")
+	ErrPlacementTagDuplicate    = errors.New("Invalid placement constraints: duplicate placement tag")
+	ErrIsolationSegmentConflict = errors.New("Invalid placement constraints: isolation segment differs from the network's")
+)
+
+// PlacementConstraints routes work to dedicated cells. It is embedded in the
+// desire, task and staging requests so its fields sit at the top level of
+// their JSON. For desire requests IsolationSegment is the source of truth; the
+// network's isolation_segment property is only used when it is empty.
+type PlacementConstraints struct {
+	IsolationSegment      string   `json:"isolation_segment,omitempty"`
+	RequiredPlacementTags []string `json:"required_placement_tags,omitempty"`
+	OptionalPlacementTags []string `json:"optional_placement_tags,omitempty"`
+}
+
+func (c PlacementConstraints) ValidatePlacementConstraints() error {
+	seen := map[string]bool{}
+	for _, tag := range append(append([]string{}, c.RequiredPlacementTags...), c.OptionalPlacementTags...) {
+		if tag == "" {
+			return ErrPlacementTagEmpty
+		}
+		if seen[tag] {
+			return ErrPlacementTagDuplicate
+		}
+		seen[tag] = true
+	}
+	return nil
+}
+
+// BBSPlacementTags returns the sorted tags a cell must advertise to run the
+// work: the isolation segment plus the required tags. BBS has no notion of
+// optional tags, so they are left for the caller to use as a preference.
+func (c PlacementConstraints) BBSPlacementTags() []string {
+	seen := map[string]bool{}
+	tags := []string{}
+
+	candidates := append([]string{c.IsolationSegment}, c.RequiredPlacementTags...)
+	for _, tag := range candidates {
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+	return tags
+}
+
+// EffectiveIsolationSegment reconciles the two places a desire request can carry an
+// isolation segment, preferring the placement constraint.
+func (r DesireAppRequestFromCC) EffectiveIsolationSegment() string {
+	if r.PlacementConstraints.IsolationSegment != "" {
+		return r.PlacementConstraints.IsolationSegment
+	}
+	if r.Network != nil {
+		return r.Network.Properties[NetworkIsolationSegmentKey]
+	}
+	return ""
+}
+
+// ValidateIsolationSegment rejects requests whose placement constraints and
+// network name different isolation segments.
+func (r DesireAppRequestFromCC) ValidateIsolationSegment() error {
+	if r.Network == nil || r.PlacementConstraints.IsolationSegment == "" {
+		return nil
+	}
+
+	networkSegment := r.Network.Properties[NetworkIsolationSegmentKey]
+	if networkSegment != "" && networkSegment != r.PlacementConstraints.IsolationSegment {
+		return observeValidation(DesireAppRequest, ErrIsolationSegmentConflict)
+	}
+	return nil
+}
+
+// BBSPlacementTags is PlacementConstraints.BBSPlacementTags with the
+// effective isolation segment.
+func (r DesireAppRequestFromCC) BBSPlacementTags() []string {
+	constraints := r.PlacementConstraints
+	constraints.IsolationSegment = r.EffectiveIsolationSegment()
+	return constraints.BBSPlacementTags()
+}
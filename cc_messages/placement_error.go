@@ -0,0 +1,92 @@
+package cc_messages
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type InsufficientResource string
+
+const (
+	InsufficientMemory     InsufficientResource = "memory"
+	InsufficientDisk       InsufficientResource = "disk"
+	InsufficientContainers InsufficientResource = "containers"
+)
+
+const (
+	PlacementFailureUnknown             = "unknown"
+	PlacementFailureMissingStack        = "missing_stack"
+	PlacementFailureMissingVolumeDriver = "missing_volume_driver"
+)
+
+// PlacementErrorDetails explains why staging or a task could not be placed.
+// Amounts are in MB for memory and disk and in containers otherwise; they are
+// nil when unknown, so that none available can be told apart from unknown.
+type PlacementErrorDetails struct {
+	InsufficientResource InsufficientResource `json:"insufficient_resource,omitempty"`
+	Required             *uint64              `json:"required,omitempty"`
+	Available            *uint64              `json:"available,omitempty"`
+	RejectedCells        int                  `json:"rejected_cells,omitempty"`
+	MissingStack         string               `json:"missing_stack,omitempty"`
+	MissingVolumeDrivers []string             `json:"missing_volume_drivers,omitempty"`
+}
+
+func NewInsufficientResourceDetails(resource InsufficientResource, required, available uint64) *PlacementErrorDetails {
+	return &PlacementErrorDetails{
+		InsufficientResource: resource,
+		Required:             &required,
+		Available:            &available,
+	}
+}
+
+// Reason is a stable key for aggregating placement failures.
+func (d PlacementErrorDetails) Reason() string {
+	switch {
+	case d.InsufficientResource != "":
+		return "insufficient_" + string(d.InsufficientResource)
+	case d.MissingStack != "":
+		return PlacementFailureMissingStack
+	case len(d.MissingVolumeDrivers) > 0:
+		return PlacementFailureMissingVolumeDriver
+	}
+	return PlacementFailureUnknown
+}
+
+// Message is a human readable explanation suitable for showing to users.
+func (d PlacementErrorDetails) Message() string {
+	var message string
+	switch {
+	case d.InsufficientResource != "":
+		message = fmt.Sprintf("insufficient %s: %s required, %s available", d.InsufficientResource, formatAmount(d.Required), formatAmount(d.Available))
+	case d.MissingStack != "":
+		message = fmt.Sprintf("no cell supports stack '%s'", d.MissingStack)
+	case len(d.MissingVolumeDrivers) > 0:
+		message = fmt.Sprintf("no cell provides volume drivers: %s", strings.Join(d.MissingVolumeDrivers, ", "))
+	default:
+		message = "no compatible cell"
+	}
+
+	if d.RejectedCells > 0 {
+		message += fmt.Sprintf(" (%d cells rejected)", d.RejectedCells)
+	}
+	return message
+}
+
+// CountPlacementFailures tallies the details by Reason.
+func CountPlacementFailures(details []*PlacementErrorDetails) map[string]int {
+	counts := map[string]int{}
+	for _, detail := range details {
+		if detail != nil {
+			counts[detail.Reason()]++
+		}
+	}
+	return counts
+}
+
+func formatAmount(amount *uint64) string {
+	if amount == nil {
+		return "unknown"
+	}
+	return strconv.FormatUint(*amount, 10)
+}
@@ -0,0 +1,75 @@
+package cc_messages_test
+
+import (
+	"encoding/json"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PlacementErrorDetails", func() {
+	It("is carried by staging errors", func() {
+		response := cc_messages.StagingResponseForCC{
+			Error: &cc_messages.StagingError{
+				Id:      cc_messages.INSUFFICIENT_RESOURCES,
+				Message: "insufficient resources: memory",
+				Details: cc_messages.NewInsufficientResourceDetails(cc_messages.InsufficientMemory, 4096, 0),
+			},
+		}
+
+		Expect(json.Marshal(response)).To(MatchJSON(`{
+			"error": {
+				"id": "InsufficientResources",
+				"message": "insufficient resources: memory",
+				"details": {"insufficient_resource": "memory", "required": 4096, "available": 0}
+			}
+		}`))
+	})
+
+	It("omits unknown amounts", func() {
+		details := cc_messages.PlacementErrorDetails{InsufficientResource: cc_messages.InsufficientContainers}
+		Expect(json.Marshal(details)).To(MatchJSON(`{"insufficient_resource": "containers"}`))
+	})
+
+	It("is omitted from task errors without details", func() {
+		Expect(json.Marshal(cc_messages.TaskError{Id: "Failed", Message: "boom"})).To(MatchJSON(`{"id": "Failed", "message": "boom"}`))
+	})
+
+	Describe("Message", func() {
+		It("explains insufficient resources", func() {
+			details := cc_messages.NewInsufficientResourceDetails(cc_messages.InsufficientDisk, 2048, 1024)
+			details.RejectedCells = 4
+			Expect(details.Message()).To(Equal("insufficient disk: 2048 required, 1024 available (4 cells rejected)"))
+
+			details = &cc_messages.PlacementErrorDetails{InsufficientResource: cc_messages.InsufficientContainers}
+			Expect(details.Message()).To(Equal("insufficient containers: unknown required, unknown available"))
+		})
+
+		It("explains missing stacks and volume drivers", func() {
+			Expect(cc_messages.PlacementErrorDetails{MissingStack: "windows2012R2"}.Message()).To(Equal("no cell supports stack 'windows2012R2'"))
+			Expect(cc_messages.PlacementErrorDetails{MissingVolumeDrivers: []string{"nfsdriver", "cephdriver"}}.Message()).To(Equal("no cell provides volume drivers: nfsdriver, cephdriver"))
+		})
+	})
+
+	Describe("CountPlacementFailures", func() {
+		It("tallies the failures by reason", func() {
+			Expect(cc_messages.CountPlacementFailures([]*cc_messages.PlacementErrorDetails{
+				{InsufficientResource: cc_messages.InsufficientMemory},
+				{InsufficientResource: cc_messages.InsufficientMemory},
+				{InsufficientResource: cc_messages.InsufficientContainers},
+				{MissingStack: "windows2012R2"},
+				{MissingVolumeDrivers: []string{"nfsdriver"}},
+				{},
+				nil,
+			})).To(Equal(map[string]int{
+				"insufficient_memory":     2,
+				"insufficient_containers": 1,
+				"missing_stack":           1,
+				"missing_volume_driver":   1,
+				"unknown":                 1,
+			}))
+		})
+	})
+})
@@ -0,0 +1,108 @@
+package cc_messages_test
+
+import (
+	"encoding/json"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PlacementConstraints", func() {
+	It("unmarshals from the top level of the requests", func() {
+		payload := []byte(`{
+			"isolation_segment": "regulated",
+			"required_placement_tags": ["pci"],
+			"optional_placement_tags": ["ssd"]
+		}`)
+		expected := cc_messages.PlacementConstraints{
+			IsolationSegment:      "regulated",
+			RequiredPlacementTags: []string{"pci"},
+			OptionalPlacementTags: []string{"ssd"},
+		}
+
+		var desireRequest cc_messages.DesireAppRequestFromCC
+		Expect(json.Unmarshal(payload, &desireRequest)).To(Succeed())
+		Expect(desireRequest.PlacementConstraints).To(Equal(expected))
+
+		var taskRequest cc_messages.TaskRequestFromCC
+		Expect(json.Unmarshal(payload, &taskRequest)).To(Succeed())
+		Expect(taskRequest.PlacementConstraints).To(Equal(expected))
+
+		var stagingRequest cc_messages.StagingRequestFromCC
+		Expect(json.Unmarshal(payload, &stagingRequest)).To(Succeed())
+		Expect(stagingRequest.PlacementConstraints).To(Equal(expected))
+	})
+
+	It("is omitted from the JSON when empty", func() {
+		payload, err := json.Marshal(cc_messages.TaskRequestFromCC{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(payload)).NotTo(ContainSubstring("placement"))
+		Expect(string(payload)).NotTo(ContainSubstring("isolation_segment"))
+	})
+
+	Describe("BBSPlacementTags", func() {
+		It("combines the isolation segment and required tags", func() {
+			constraints := cc_messages.PlacementConstraints{
+				IsolationSegment:      "regulated",
+				RequiredPlacementTags: []string{"pci", "regulated"},
+				OptionalPlacementTags: []string{"ssd"},
+			}
+			Expect(constraints.BBSPlacementTags()).To(Equal([]string{"pci", "regulated"}))
+		})
+
+		It("is empty without constraints", func() {
+			Expect(cc_messages.PlacementConstraints{}.BBSPlacementTags()).To(BeEmpty())
+		})
+	})
+
+	Describe("ValidatePlacementConstraints", func() {
+		It("rejects empty and duplicate tags", func() {
+			Expect(cc_messages.PlacementConstraints{RequiredPlacementTags: []string{""}}.ValidatePlacementConstraints()).To(Equal(cc_messages.ErrPlacementTagEmpty))
+			Expect(cc_messages.PlacementConstraints{
+				RequiredPlacementTags: []string{"pci"},
+				OptionalPlacementTags: []string{"pci"},
+			}.ValidatePlacementConstraints()).To(Equal(cc_messages.ErrPlacementTagDuplicate))
+			Expect(cc_messages.PlacementConstraints{RequiredPlacementTags: []string{"pci"}}.ValidatePlacementConstraints()).To(Succeed())
+		})
+	})
+
+	Context("on a desire request", func() {
+		var request cc_messages.DesireAppRequestFromCC
+
+		BeforeEach(func() {
+			request = cc_messages.DesireAppRequestFromCC{
+				PlacementConstraints: cc_messages.PlacementConstraints{RequiredPlacementTags: []string{"pci"}},
+				Network: &models.Network{Properties: map[string]string{
+					cc_messages.NetworkIsolationSegmentKey: "segment",
+				}},
+			}
+		})
+
+		It("falls back to the network's isolation segment", func() {
+			Expect(request.EffectiveIsolationSegment()).To(Equal("segment"))
+			Expect(request.BBSPlacementTags()).To(Equal([]string{"pci", "segment"}))
+			Expect(request.ValidateIsolationSegment()).To(Succeed())
+		})
+
+		It("accepts the same isolation segment in both places", func() {
+			request.IsolationSegment = "segment"
+			Expect(request.ValidateIsolationSegment()).To(Succeed())
+			Expect(request.BBSPlacementTags()).To(Equal([]string{"pci", "segment"}))
+		})
+
+		It("rejects differing isolation segments", func() {
+			request.IsolationSegment = "other"
+			Expect(request.ValidateIsolationSegment()).To(Equal(cc_messages.ErrIsolationSegmentConflict))
+			Expect(request.EffectiveIsolationSegment()).To(Equal("other"))
+		})
+
+		It("has no isolation segment when neither names one", func() {
+			request.Network = nil
+			Expect(request.EffectiveIsolationSegment()).To(BeEmpty())
+			Expect(request.ValidateIsolationSegment()).To(Succeed())
+		})
+	})
+})
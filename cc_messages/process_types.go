@@ -0,0 +1,55 @@
+package cc_messages
+
+import "encoding/json"
+
+const DefaultProcessType = "web"
+
+// ProcessTypes maps a process type, such as web, worker or clock, to the
+// start command detected at staging time.
+type ProcessTypes map[string]string
+
+// StagingResult is the typed form of StagingResponseForCC.Result.
+type StagingResult struct {
+	LifecycleType     string           `json:"lifecycle_type"`
+	LifecycleMetadata *json.RawMessage `json:"lifecycle_metadata,omitempty"`
+	ProcessTypes      ProcessTypes     `json:"process_types"`
+	ExecutionMetadata string           `json:"execution_metadata"`
+}
+
+func (r StagingResponseForCC) StagingResult() (*StagingResult, error) {
+	if r.Result == nil {
+		return nil, nil
+	}
+	defer timeConversion(StagingResultConversion)()
+
+	result := &StagingResult{}
+	err := json.Unmarshal(*r.Result, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func NewStagingResponseForCC(result StagingResult) (StagingResponseForCC, error) {
+	defer timeConversion(StagingResponseConversion)()
+
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return StagingResponseForCC{}, err
+	}
+
+	raw := json.RawMessage(payload)
+	return StagingResponseForCC{Result: &raw}, nil
+}
+
+// StartCommand picks the command for the process type: the user-provided
+// command wins, otherwise the staged default for that type is used.
+func (p ProcessTypes) StartCommand(processType, userCommand string) string {
+	if userCommand != "" {
+		return userCommand
+	}
+	if processType == "" {
+		processType = DefaultProcessType
+	}
+	return p[processType]
+}
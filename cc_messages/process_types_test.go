@@ -0,0 +1,69 @@
+package cc_messages_test
+
+import (
+	"encoding/json"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProcessTypes", func() {
+	Describe("StagingResult", func() {
+		It("decodes the process types from the staging response", func() {
+			result := json.RawMessage(`{
+				"lifecycle_type": "buildpack",
+				"lifecycle_metadata": {"detected_buildpack": "ruby"},
+				"process_types": {"web": "rackup", "worker": "rake jobs:work"},
+				"execution_metadata": ""
+			}`)
+			response := cc_messages.StagingResponseForCC{Result: &result}
+
+			stagingResult, err := response.StagingResult()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stagingResult.LifecycleType).To(Equal("buildpack"))
+			Expect(stagingResult.ProcessTypes).To(Equal(cc_messages.ProcessTypes{"web": "rackup", "worker": "rake jobs:work"}))
+		})
+
+		It("is nil when staging failed", func() {
+			response := cc_messages.StagingResponseForCC{Error: &cc_messages.StagingError{Id: cc_messages.STAGING_ERROR}}
+
+			stagingResult, err := response.StagingResult()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stagingResult).To(BeNil())
+		})
+
+		It("builds a staging response from a typed result", func() {
+			response, err := cc_messages.NewStagingResponseForCC(cc_messages.StagingResult{
+				LifecycleType: "docker",
+				ProcessTypes:  cc_messages.ProcessTypes{"web": "/bin/start"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.Marshal(response)).To(MatchJSON(`{
+				"result": {"lifecycle_type": "docker", "process_types": {"web": "/bin/start"}, "execution_metadata": ""}
+			}`))
+		})
+	})
+
+	Describe("StartCommand", func() {
+		processTypes := cc_messages.ProcessTypes{"web": "rackup", "worker": "rake jobs:work"}
+
+		It("prefers the user command", func() {
+			Expect(processTypes.StartCommand("worker", "./custom")).To(Equal("./custom"))
+		})
+
+		It("falls back to the staged command for the process type", func() {
+			Expect(processTypes.StartCommand("worker", "")).To(Equal("rake jobs:work"))
+		})
+
+		It("uses the web process type by default", func() {
+			Expect(processTypes.StartCommand("", "")).To(Equal("rackup"))
+		})
+
+		It("is empty for an unknown process type", func() {
+			Expect(processTypes.StartCommand("clock", "")).To(BeEmpty())
+		})
+	})
+})
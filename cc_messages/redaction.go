@@ -0,0 +1,186 @@
+package cc_messages
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+const RedactedValue = "[REDACTED]"
+
+// SecretEnvironmentVariables is the deny-list of environment variable names
+// whose values are masked by Redacted. Entries are case-insensitive glob
+// patterns as understood by filepath.Match.
+var SecretEnvironmentVariables = []string{
+	"VCAP_SERVICES",
+	"*PASSWORD*",
+	"*SECRET*",
+	"*TOKEN*",
+	"*CREDENTIAL*",
+	"*PRIVATE_KEY*",
+	"*ACCESS_KEY*",
+}
+
+var secretLifecycleDataKeys = []string{"docker_user", "docker_password", "docker_email", "docker_token", "docker_auth_header"}
+
+func IsSecretEnvironmentVariable(name string) bool {
+	name = strings.ToUpper(name)
+	for _, pattern := range SecretEnvironmentVariables {
+		if matched, _ := filepath.Match(strings.ToUpper(pattern), name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func RedactEnvironment(env []*models.EnvironmentVariable) []*models.EnvironmentVariable {
+	if env == nil {
+		return nil
+	}
+
+	redacted := make([]*models.EnvironmentVariable, len(env))
+	for i, envVar := range env {
+		if envVar == nil {
+			continue
+		}
+
+		copied := *envVar
+		if IsSecretEnvironmentVariable(copied.Name) {
+			copied.Value = RedactedValue
+		}
+		redacted[i] = &copied
+	}
+	return redacted
+}
+
+func redactString(value string) string {
+	if value == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+func (r DesireAppRequestFromCC) Redacted() DesireAppRequestFromCC {
+	r.DockerCredentials = r.RedactedDockerCredentials()
+	r.Environment = RedactEnvironment(r.Environment)
+	if r.Sidecars != nil {
+		sidecars := make([]Sidecar, len(r.Sidecars))
+		for i, sidecar := range r.Sidecars {
+			sidecar.Environment = RedactEnvironment(sidecar.Environment)
+			sidecars[i] = sidecar
+		}
+		r.Sidecars = sidecars
+	}
+	return r
+}
+
+type redactedDesireAppRequestFromCC DesireAppRequestFromCC
+
+func (r DesireAppRequestFromCC) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, formatDirective(f, verb), redactedDesireAppRequestFromCC(r.Redacted()))
+}
+
+func (r DesireAppRequestFromCC) LogValue() slog.Value {
+	return slog.AnyValue(redactedDesireAppRequestFromCC(r.Redacted()))
+}
+
+func (d DockerStagingData) Redacted() DockerStagingData {
+	d.DockerCredentials = d.RedactedDockerCredentials()
+	return d
+}
+
+type redactedDockerStagingData DockerStagingData
+
+func (d DockerStagingData) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, formatDirective(f, verb), redactedDockerStagingData(d.Redacted()))
+}
+
+func (d DockerStagingData) LogValue() slog.Value {
+	return slog.AnyValue(redactedDockerStagingData(d.Redacted()))
+}
+
+// Redacted masks secret environment variables and any docker credentials
+// carried in the lifecycle data.
+func (r StagingRequestFromCC) Redacted() StagingRequestFromCC {
+	r.Environment = RedactEnvironment(r.Environment)
+	r.LifecycleData = redactLifecycleData(r.LifecycleData)
+	return r
+}
+
+type redactedStagingRequestFromCC StagingRequestFromCC
+
+func (r StagingRequestFromCC) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, formatDirective(f, verb), redactedStagingRequestFromCC(r.Redacted()))
+}
+
+func (r StagingRequestFromCC) LogValue() slog.Value {
+	return slog.AnyValue(redactedStagingRequestFromCC(r.Redacted()))
+}
+
+func (r TaskRequestFromCC) Redacted() TaskRequestFromCC {
+	r.EnvironmentVariables = RedactEnvironment(r.EnvironmentVariables)
+	return r
+}
+
+type redactedTaskRequestFromCC TaskRequestFromCC
+
+func (r TaskRequestFromCC) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, formatDirective(f, verb), redactedTaskRequestFromCC(r.Redacted()))
+}
+
+func (r TaskRequestFromCC) LogValue() slog.Value {
+	return slog.AnyValue(redactedTaskRequestFromCC(r.Redacted()))
+}
+
+func redactLifecycleData(data *json.RawMessage) *json.RawMessage {
+	if data == nil {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if json.Unmarshal(*data, &fields) != nil {
+		return data
+	}
+
+	redacted := false
+	for _, key := range secretLifecycleDataKeys {
+		if value, ok := fields[key].(string); ok && value != "" {
+			fields[key] = RedactedValue
+			redacted = true
+		}
+	}
+
+	if !redacted {
+		return data
+	}
+
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return data
+	}
+
+	raw := json.RawMessage(payload)
+	return &raw
+}
+
+// formatDirective rebuilds the verb, flags, width and precision that were
+// used to format a value so they can be applied to its redacted copy.
+func formatDirective(f fmt.State, verb rune) string {
+	directive := "%"
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			directive += string(flag)
+		}
+	}
+	if width, ok := f.Width(); ok {
+		directive += fmt.Sprintf("%d", width)
+	}
+	if precision, ok := f.Precision(); ok {
+		directive += fmt.Sprintf(".%d", precision)
+	}
+	return directive + string(verb)
+}
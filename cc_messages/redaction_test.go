@@ -0,0 +1,175 @@
+package cc_messages_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redaction", func() {
+	Describe("IsSecretEnvironmentVariable", func() {
+		It("matches names on the deny-list regardless of case", func() {
+			Expect(cc_messages.IsSecretEnvironmentVariable("VCAP_SERVICES")).To(BeTrue())
+			Expect(cc_messages.IsSecretEnvironmentVariable("db_password")).To(BeTrue())
+			Expect(cc_messages.IsSecretEnvironmentVariable("GITHUB_TOKEN")).To(BeTrue())
+		})
+
+		It("does not match other names", func() {
+			Expect(cc_messages.IsSecretEnvironmentVariable("VCAP_APPLICATION")).To(BeFalse())
+			Expect(cc_messages.IsSecretEnvironmentVariable("PORT")).To(BeFalse())
+		})
+
+		Context("when the deny-list is changed", func() {
+			var original []string
+
+			BeforeEach(func() {
+				original = cc_messages.SecretEnvironmentVariables
+				cc_messages.SecretEnvironmentVariables = []string{"PORT"}
+			})
+
+			AfterEach(func() {
+				cc_messages.SecretEnvironmentVariables = original
+			})
+
+			It("uses the new deny-list", func() {
+				Expect(cc_messages.IsSecretEnvironmentVariable("PORT")).To(BeTrue())
+				Expect(cc_messages.IsSecretEnvironmentVariable("VCAP_SERVICES")).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("DesireAppRequestFromCC", func() {
+		var request cc_messages.DesireAppRequestFromCC
+
+		BeforeEach(func() {
+			request = cc_messages.DesireAppRequestFromCC{
+				DockerCredentials: cc_messages.DockerCredentials{
+					DockerLoginServer: "https://index.docker.io/v1/",
+					DockerUser:        "docker-user",
+					DockerPassword:    "docker-password",
+					DockerEmail:       "docker-user@example.com",
+				},
+				ProcessGuid:    "process-guid",
+				DockerImageUrl: "docker:///diego/image",
+				Environment: []*models.EnvironmentVariable{
+					{Name: "FOO", Value: "BAR"},
+					{Name: "VCAP_SERVICES", Value: `{"p-mysql":[]}`},
+				},
+			}
+		})
+
+		It("masks the credentials and secret environment in a copy", func() {
+			redacted := request.Redacted()
+
+			Expect(redacted.ProcessGuid).To(Equal("process-guid"))
+			Expect(redacted.DockerLoginServer).To(Equal("https://index.docker.io/v1/"))
+			Expect(redacted.DockerUser).To(Equal(cc_messages.RedactedValue))
+			Expect(redacted.DockerPassword).To(Equal(cc_messages.RedactedValue))
+			Expect(redacted.DockerEmail).To(Equal(cc_messages.RedactedValue))
+			Expect(redacted.Environment).To(Equal([]*models.EnvironmentVariable{
+				{Name: "FOO", Value: "BAR"},
+				{Name: "VCAP_SERVICES", Value: cc_messages.RedactedValue},
+			}))
+		})
+
+		It("masks secret environment of sidecars", func() {
+			request.Sidecars = []cc_messages.Sidecar{{
+				Name:        "log-shipper",
+				Environment: []*models.EnvironmentVariable{{Name: "API_TOKEN", Value: "token"}},
+			}}
+
+			redacted := request.Redacted()
+			Expect(redacted.Sidecars[0].Environment[0].Value).To(Equal(cc_messages.RedactedValue))
+			Expect(request.Sidecars[0].Environment[0].Value).To(Equal("token"))
+		})
+
+		It("does not modify the original", func() {
+			request.Redacted()
+
+			Expect(request.DockerPassword).To(Equal("docker-password"))
+			Expect(request.Environment[1].Value).To(Equal(`{"p-mysql":[]}`))
+		})
+
+		It("leaves unset credentials empty", func() {
+			request.DockerUser = ""
+			Expect(request.Redacted().DockerUser).To(BeEmpty())
+		})
+
+		It("masks the credentials when formatted", func() {
+			for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+				formatted := fmt.Sprintf(verb, request)
+				Expect(formatted).NotTo(ContainSubstring("docker-password"))
+				Expect(formatted).To(ContainSubstring(cc_messages.RedactedValue))
+			}
+		})
+
+		It("masks the credentials and environment when logged", func() {
+			buffer := &bytes.Buffer{}
+			logger := slog.New(slog.NewJSONHandler(buffer, nil))
+
+			logger.Info("desire-app", "request", request)
+
+			Expect(buffer.String()).NotTo(ContainSubstring("docker-password"))
+			Expect(buffer.String()).NotTo(ContainSubstring("p-mysql"))
+			Expect(buffer.String()).To(ContainSubstring("process-guid"))
+		})
+
+		It("still marshals the credentials verbatim", func() {
+			payload, err := json.Marshal(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(payload)).To(ContainSubstring("docker-password"))
+		})
+	})
+
+	Describe("DockerStagingData", func() {
+		It("masks the credentials", func() {
+			data := cc_messages.DockerStagingData{
+				DockerCredentials: cc_messages.DockerCredentials{
+					DockerPassword: "docker-password",
+				},
+				DockerImageUrl: "docker:///diego/image",
+			}
+
+			Expect(data.Redacted()).To(Equal(cc_messages.DockerStagingData{
+				DockerCredentials: cc_messages.DockerCredentials{
+					DockerPassword: cc_messages.RedactedValue,
+				},
+				DockerImageUrl: "docker:///diego/image",
+			}))
+			Expect(fmt.Sprintf("%+v", data)).NotTo(ContainSubstring("docker-password"))
+		})
+	})
+
+	Describe("StagingRequestFromCC", func() {
+		It("masks docker credentials in the lifecycle data", func() {
+			lifecycleData := json.RawMessage(`{"docker_image":"docker:///diego/image","docker_password":"docker-password"}`)
+			request := cc_messages.StagingRequestFromCC{
+				Lifecycle:     "docker",
+				LifecycleData: &lifecycleData,
+			}
+
+			redacted := request.Redacted()
+			Expect(string(*redacted.LifecycleData)).To(MatchJSON(`{"docker_image":"docker:///diego/image","docker_password":"[REDACTED]"}`))
+			Expect(string(*request.LifecycleData)).To(ContainSubstring("docker-password"))
+		})
+	})
+
+	Describe("TaskRequestFromCC", func() {
+		It("masks secret environment variables", func() {
+			request := cc_messages.TaskRequestFromCC{
+				EnvironmentVariables: []*models.EnvironmentVariable{
+					{Name: "DB_PASSWORD", Value: "hunter2"},
+				},
+			}
+
+			Expect(request.Redacted().EnvironmentVariables[0].Value).To(Equal(cc_messages.RedactedValue))
+		})
+	})
+})
@@ -0,0 +1,97 @@
+package cc_messages
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+const DefaultAppLogSource = "APP"
+
+var (
+	ErrSidecarNameEmpty     = errors.New("Invalid sidecar: empty name")
+	ErrSidecarNameDuplicate = errors.New("Invalid sidecar: duplicate name")
+	ErrSidecarCommandEmpty  = errors.New("Invalid sidecar: empty command")
+	ErrSidecarMemoryInvalid = errors.New("Invalid sidecar: memory must be positive")
+	ErrSidecarMemoryTooHigh = errors.New("Invalid sidecar: memory exceeds the app's memory")
+)
+
+// Sidecar is a process that runs next to the app in the same container. Its
+// MemoryMB is a share of the app's MemoryMB, which is the limit of the whole
+// container.
+type Sidecar struct {
+	Name        string                        `json:"name"`
+	Command     string                        `json:"command"`
+	MemoryMB    int                           `json:"memory_mb"`
+	Environment []*models.EnvironmentVariable `json:"environment,omitempty"`
+}
+
+// ValidateSidecars checks that every sidecar is named uniquely, has a command
+// and a positive memory share, and that the shares together fit in the app's
+// memory.
+func (r DesireAppRequestFromCC) ValidateSidecars() error {
+	return observeValidation(DesireAppRequest, validateSidecars(r.Sidecars, r.MemoryMB))
+}
+
+func validateSidecars(sidecars []Sidecar, appMemoryMB int) error {
+	names := map[string]bool{}
+	memoryMB := 0
+
+	for _, sidecar := range sidecars {
+		if sidecar.Name == "" {
+			return ErrSidecarNameEmpty
+		}
+		if names[sidecar.Name] {
+			return ErrSidecarNameDuplicate
+		}
+		names[sidecar.Name] = true
+
+		if sidecar.Command == "" {
+			return ErrSidecarCommandEmpty
+		}
+		if sidecar.MemoryMB <= 0 {
+			return ErrSidecarMemoryInvalid
+		}
+		memoryMB += sidecar.MemoryMB
+	}
+
+	if memoryMB > appMemoryMB {
+		return ErrSidecarMemoryTooHigh
+	}
+	return nil
+}
+
+// RunAction runs the sidecar's command through a shell, with its own
+// environment layered over the app's. The sidecar cannot override system
+// variables.
+func (s Sidecar) RunAction(user, logSource string, env []*models.EnvironmentVariable) *models.RunAction {
+	return &models.RunAction{
+		Path:      "/bin/sh",
+		Args:      []string{"-c", s.Command},
+		Env:       MergeUserEnvironment(env, s.Environment),
+		User:      user,
+		LogSource: logSource + "/SIDECAR/" + s.Name,
+	}
+}
+
+// RunActionWithSidecars wraps the app's run action, as built by a recipe
+// builder, in a codependent action with one run action per sidecar, so that
+// the container stops when any of them exits. Sidecars inherit the app
+// action's environment. Without sidecars the app's action is returned
+// unchanged.
+func (r DesireAppRequestFromCC) RunActionWithSidecars(appAction *models.RunAction) models.ActionInterface {
+	if len(r.Sidecars) == 0 {
+		return appAction
+	}
+
+	logSource := r.LogSource
+	if logSource == "" {
+		logSource = DefaultAppLogSource
+	}
+
+	actions := []models.ActionInterface{appAction}
+	for _, sidecar := range r.Sidecars {
+		actions = append(actions, sidecar.RunAction(appAction.User, logSource, appAction.Env))
+	}
+	return models.Codependent(actions...)
+}
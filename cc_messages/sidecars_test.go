@@ -0,0 +1,113 @@
+package cc_messages_test
+
+import (
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sidecars", func() {
+	var request cc_messages.DesireAppRequestFromCC
+
+	BeforeEach(func() {
+		request = cc_messages.DesireAppRequestFromCC{
+			MemoryMB:    512,
+			Environment: []*models.EnvironmentVariable{{Name: "FOO", Value: "app"}},
+			Sidecars: []cc_messages.Sidecar{
+				{Name: "envoy", Command: "/etc/cf-assets/envoy", MemoryMB: 64},
+				{
+					Name:     "log-shipper",
+					Command:  "./ship-logs",
+					MemoryMB: 32,
+					Environment: []*models.EnvironmentVariable{
+						{Name: "FOO", Value: "shipper"},
+						{Name: "PORT", Value: "9999"},
+					},
+				},
+			},
+		}
+	})
+
+	Describe("ValidateSidecars", func() {
+		It("accepts valid sidecars", func() {
+			Expect(request.ValidateSidecars()).To(Succeed())
+		})
+
+		It("rejects duplicate names", func() {
+			request.Sidecars[1].Name = "envoy"
+			Expect(request.ValidateSidecars()).To(Equal(cc_messages.ErrSidecarNameDuplicate))
+		})
+
+		It("rejects empty commands", func() {
+			request.Sidecars[0].Command = ""
+			Expect(request.ValidateSidecars()).To(Equal(cc_messages.ErrSidecarCommandEmpty))
+		})
+
+		It("rejects memory shares that are not positive", func() {
+			request.Sidecars[0].MemoryMB = 0
+			Expect(request.ValidateSidecars()).To(Equal(cc_messages.ErrSidecarMemoryInvalid))
+
+			request.Sidecars[0].MemoryMB = -64
+			Expect(request.ValidateSidecars()).To(Equal(cc_messages.ErrSidecarMemoryInvalid))
+		})
+
+		It("rejects memory shares that exceed the app's memory together", func() {
+			request.Sidecars[0].MemoryMB = 480
+			Expect(request.ValidateSidecars()).To(Succeed())
+
+			request.Sidecars[0].MemoryMB = 481
+			Expect(request.ValidateSidecars()).To(Equal(cc_messages.ErrSidecarMemoryTooHigh))
+		})
+	})
+
+	Describe("RunActionWithSidecars", func() {
+		var appAction *models.RunAction
+
+		BeforeEach(func() {
+			appAction = &models.RunAction{
+				Path: "/tmp/lifecycle/launcher",
+				Env: []*models.EnvironmentVariable{
+					{Name: "FOO", Value: "app"},
+					{Name: "PORT", Value: "8080"},
+				},
+				User:      "vcap",
+				LogSource: "APP",
+			}
+		})
+
+		It("runs the sidecars codependently with the app, with the app action's environment", func() {
+			action := request.RunActionWithSidecars(appAction)
+
+			Expect(action).To(Equal(models.Codependent(
+				appAction,
+				&models.RunAction{
+					Path: "/bin/sh",
+					Args: []string{"-c", "/etc/cf-assets/envoy"},
+					Env: []*models.EnvironmentVariable{
+						{Name: "FOO", Value: "app"},
+						{Name: "PORT", Value: "8080"},
+					},
+					User:      "vcap",
+					LogSource: "APP/SIDECAR/envoy",
+				},
+				&models.RunAction{
+					Path: "/bin/sh",
+					Args: []string{"-c", "./ship-logs"},
+					Env: []*models.EnvironmentVariable{
+						{Name: "FOO", Value: "shipper"},
+						{Name: "PORT", Value: "8080"},
+					},
+					User:      "vcap",
+					LogSource: "APP/SIDECAR/log-shipper",
+				},
+			)))
+		})
+
+		It("returns the app's action when there are no sidecars", func() {
+			request.Sidecars = nil
+			Expect(request.RunActionWithSidecars(appAction)).To(BeIdenticalTo(appAction))
+		})
+	})
+})
@@ -19,11 +19,14 @@ const (
 )
 
 type StagingError struct {
-	Id      StagingErrorID `json:"id"`
-	Message string         `json:"message"`
+	Id      StagingErrorID         `json:"id"`
+	Message string                 `json:"message"`
+	Details *PlacementErrorDetails `json:"details,omitempty"`
 }
 
 type StagingRequestFromCC struct {
+	PlacementConstraints
+
 	AppId              string                        `json:"app_id"`
 	FileDescriptors    int                           `json:"file_descriptors"`
 	MemoryMB           int                           `json:"memory_mb"`
@@ -47,11 +50,9 @@ type BuildpackStagingData struct {
 }
 
 type DockerStagingData struct {
-	DockerImageUrl    string `json:"docker_image"`
-	DockerLoginServer string `json:"docker_login_server,omitempty"`
-	DockerUser        string `json:"docker_user,omitempty"`
-	DockerPassword    string `json:"docker_password,omitempty"`
-	DockerEmail       string `json:"docker_email,omitempty"`
+	DockerCredentials
+
+	DockerImageUrl string `json:"docker_image"`
 }
 
 const CUSTOM_BUILDPACK = "custom"
@@ -0,0 +1,92 @@
+package cc_messages
+
+// The validators of the embedded PlacementConstraints and DockerCredentials,
+// and of the environment, egress rules and deployment strategy, do not know
+// which request they belong to, so the requests wrap them to report their
+// failures to the instrumentation.
+
+func (r DesireAppRequestFromCC) ValidatePlacementConstraints() error {
+	return observeValidation(DesireAppRequest, r.PlacementConstraints.ValidatePlacementConstraints())
+}
+
+func (r StagingRequestFromCC) ValidatePlacementConstraints() error {
+	return observeValidation(StagingRequest, r.PlacementConstraints.ValidatePlacementConstraints())
+}
+
+func (r TaskRequestFromCC) ValidatePlacementConstraints() error {
+	return observeValidation(TaskRequest, r.PlacementConstraints.ValidatePlacementConstraints())
+}
+
+func (r DesireAppRequestFromCC) ValidateDockerCredentials() error {
+	return observeValidation(DesireAppRequest, r.DockerCredentials.ValidateDockerCredentials())
+}
+
+// ValidateDockerCredentials reports failures as failures of staging
+// requests, the only requests that carry DockerStagingData.
+func (d DockerStagingData) ValidateDockerCredentials() error {
+	return observeValidation(StagingRequest, d.DockerCredentials.ValidateDockerCredentials())
+}
+
+func (r DesireAppRequestFromCC) ValidateEnvironment() error {
+	return observeValidation(DesireAppRequest, ValidateEnvironment(r.Environment))
+}
+
+func (r StagingRequestFromCC) ValidateEnvironment() error {
+	return observeValidation(StagingRequest, ValidateEnvironment(r.Environment))
+}
+
+func (r TaskRequestFromCC) ValidateEnvironment() error {
+	return observeValidation(TaskRequest, ValidateEnvironment(r.EnvironmentVariables))
+}
+
+func (r DesireAppRequestFromCC) ValidateEgressRules() error {
+	return observeValidation(DesireAppRequest, EgressRules(r.EgressRules).Validate())
+}
+
+func (r StagingRequestFromCC) ValidateEgressRules() error {
+	return observeValidation(StagingRequest, EgressRules(r.EgressRules).Validate())
+}
+
+func (r TaskRequestFromCC) ValidateEgressRules() error {
+	return observeValidation(TaskRequest, EgressRules(r.EgressRules).Validate())
+}
+
+// ValidateDeploymentStrategy accepts a request without a strategy.
+func (r DesireAppRequestFromCC) ValidateDeploymentStrategy() error {
+	if r.DeploymentStrategy == nil {
+		return nil
+	}
+	return observeValidation(DesireAppRequest, r.DeploymentStrategy.Validate())
+}
+
+var validationErrorIDs = map[error]string{
+	ErrDockerCredentialsConflict:   "DockerCredentialsConflict",
+	ErrDockerCredentialsIncomplete: "DockerCredentialsIncomplete",
+	ErrPlacementTagEmpty:           "PlacementTagEmpty",
+	ErrPlacementTagDuplicate:       "PlacementTagDuplicate",
+	ErrIsolationSegmentConflict:    "IsolationSegmentConflict",
+	ErrSidecarNameEmpty:            "SidecarNameEmpty",
+	ErrSidecarNameDuplicate:        "SidecarNameDuplicate",
+	ErrSidecarCommandEmpty:         "SidecarCommandEmpty",
+	ErrSidecarMemoryInvalid:        "SidecarMemoryInvalid",
+	ErrSidecarMemoryTooHigh:        "SidecarMemoryTooHigh",
+	ErrDeploymentStrategyNegative:  "DeploymentStrategyNegative",
+	ErrDeploymentStrategyStalled:   "DeploymentStrategyStalled",
+}
+
+// ValidationErrorID is a stable key for aggregating validation failures.
+func ValidationErrorID(err error) string {
+	switch err.(type) {
+	case InvalidEnvironmentVariableNameError:
+		return "InvalidEnvironmentVariableName"
+	case EgressRuleError:
+		return "InvalidEgressRule"
+	case VolumeMountError:
+		return "InvalidVolumeMount"
+	}
+
+	if id, ok := validationErrorIDs[err]; ok {
+		return id
+	}
+	return "ValidationError"
+}
@@ -0,0 +1,124 @@
+package cc_messages
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+const (
+	VcapApplicationEnvKey = "VCAP_APPLICATION"
+	VcapServicesEnvKey    = "VCAP_SERVICES"
+)
+
+var ErrVcapApplicationMissing = errors.New("VCAP_APPLICATION not found in environment")
+
+type VcapApplication struct {
+	ApplicationId      string                `json:"application_id"`
+	ApplicationName    string                `json:"application_name"`
+	ApplicationUris    []string              `json:"application_uris"`
+	ApplicationVersion string                `json:"application_version"`
+	CfApi              string                `json:"cf_api,omitempty"`
+	Host               string                `json:"host,omitempty"`
+	InstanceId         string                `json:"instance_id,omitempty"`
+	InstanceIndex      *int                  `json:"instance_index,omitempty"`
+	Limits             VcapApplicationLimits `json:"limits"`
+	Name               string                `json:"name"`
+	SpaceId            string                `json:"space_id"`
+	SpaceName          string                `json:"space_name"`
+	Uris               []string              `json:"uris"`
+	Version            string                `json:"version"`
+}
+
+type VcapApplicationLimits struct {
+	Disk int    `json:"disk"`
+	Fds  uint64 `json:"fds"`
+	Mem  int    `json:"mem"`
+}
+
+type VcapServices map[string][]VcapService
+
+type VcapService struct {
+	Name           string                 `json:"name"`
+	Label          string                 `json:"label"`
+	Tags           []string               `json:"tags"`
+	Plan           string                 `json:"plan"`
+	Provider       *string                `json:"provider,omitempty"`
+	Credentials    map[string]interface{} `json:"credentials"`
+	SyslogDrainUrl string                 `json:"syslog_drain_url,omitempty"`
+}
+
+func VcapApplicationFromEnvironment(env []*models.EnvironmentVariable) (*VcapApplication, error) {
+	value, ok := LookupEnvironment(env, VcapApplicationEnvKey)
+	if !ok {
+		return nil, ErrVcapApplicationMissing
+	}
+
+	vcapApplication := &VcapApplication{}
+	err := json.Unmarshal([]byte(value), vcapApplication)
+	if err != nil {
+		return nil, err
+	}
+
+	return vcapApplication, nil
+}
+
+// VcapServicesFromEnvironment decodes VCAP_SERVICES. An app without bound
+// services may have no VCAP_SERVICES at all, so a missing variable decodes to
+// an empty set of services.
+func VcapServicesFromEnvironment(env []*models.EnvironmentVariable) (VcapServices, error) {
+	vcapServices := VcapServices{}
+
+	value, ok := LookupEnvironment(env, VcapServicesEnvKey)
+	if !ok || value == "" {
+		return vcapServices, nil
+	}
+
+	err := json.Unmarshal([]byte(value), &vcapServices)
+	if err != nil {
+		return nil, err
+	}
+
+	return vcapServices, nil
+}
+
+func (s VcapServices) FindByName(name string) (VcapService, bool) {
+	for _, label := range s.labels() {
+		for _, service := range s[label] {
+			if service.Name == name {
+				return service, true
+			}
+		}
+	}
+	return VcapService{}, false
+}
+
+func (s VcapServices) FindByLabel(label string) []VcapService {
+	return s[label]
+}
+
+func (s VcapServices) FindByTag(tag string) []VcapService {
+	found := []VcapService{}
+	for _, label := range s.labels() {
+		for _, service := range s[label] {
+			for _, serviceTag := range service.Tags {
+				if serviceTag == tag {
+					found = append(found, service)
+					break
+				}
+			}
+		}
+	}
+	return found
+}
+
+func (s VcapServices) labels() []string {
+	labels := make([]string, 0, len(s))
+	for label := range s {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
@@ -0,0 +1,122 @@
+package cc_messages
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+type VolumeMounts []*models.VolumeMount
+
+type VolumeMountError struct {
+	Index  int
+	Reason string
+}
+
+func (e VolumeMountError) Error() string {
+	return fmt.Sprintf("Invalid volume mount %d: %s", e.Index, e.Reason)
+}
+
+// CellVolumeDrivers declares the volume drivers available on each cell,
+// keyed by cell id.
+type CellVolumeDrivers map[string][]string
+
+// Validate returns a VolumeMountError for the first mount that is invalid or
+// whose container path collides with, or is nested with, an earlier mount.
+// Nested mounts would shadow each other in the container.
+func (mounts VolumeMounts) Validate() error {
+	containerPaths := []string{}
+
+	for i, mount := range mounts {
+		if mount == nil {
+			return VolumeMountError{Index: i, Reason: "missing mount"}
+		}
+		if mount.Driver == "" {
+			return VolumeMountError{Index: i, Reason: "empty driver"}
+		}
+		if mount.VolumeId == "" {
+			return VolumeMountError{Index: i, Reason: "empty volume id"}
+		}
+		if !path.IsAbs(mount.ContainerPath) {
+			return VolumeMountError{Index: i, Reason: fmt.Sprintf("container path '%s' is not absolute", mount.ContainerPath)}
+		}
+		if mount.Mode != models.BindMountMode_RO && mount.Mode != models.BindMountMode_RW {
+			return VolumeMountError{Index: i, Reason: fmt.Sprintf("invalid mode %d", mount.Mode)}
+		}
+
+		containerPath := path.Clean(mount.ContainerPath)
+		for other, otherPath := range containerPaths {
+			switch {
+			case containerPath == otherPath:
+				return VolumeMountError{Index: i, Reason: fmt.Sprintf("container path '%s' collides with mount %d", containerPath, other)}
+			case isNestedPath(containerPath, otherPath):
+				return VolumeMountError{Index: i, Reason: fmt.Sprintf("container path '%s' is inside mount %d", containerPath, other)}
+			case isNestedPath(otherPath, containerPath):
+				return VolumeMountError{Index: i, Reason: fmt.Sprintf("container path '%s' contains mount %d", containerPath, other)}
+			}
+		}
+		containerPaths = append(containerPaths, containerPath)
+	}
+
+	return nil
+}
+
+// isNestedPath reports whether the clean path child is below parent.
+func isNestedPath(child, parent string) bool {
+	return strings.HasPrefix(child, strings.TrimSuffix(parent, "/")+"/")
+}
+
+// RequiredDrivers returns the sorted, distinct drivers used by the mounts.
+func (mounts VolumeMounts) RequiredDrivers() []string {
+	seen := map[string]bool{}
+	drivers := []string{}
+	for _, mount := range mounts {
+		if mount == nil || seen[mount.Driver] {
+			continue
+		}
+		seen[mount.Driver] = true
+		drivers = append(drivers, mount.Driver)
+	}
+	sort.Strings(drivers)
+	return drivers
+}
+
+func (mounts VolumeMounts) MissingDrivers(available []string) []string {
+	availableDrivers := map[string]bool{}
+	for _, driver := range available {
+		availableDrivers[driver] = true
+	}
+
+	missing := []string{}
+	for _, driver := range mounts.RequiredDrivers() {
+		if !availableDrivers[driver] {
+			missing = append(missing, driver)
+		}
+	}
+	return missing
+}
+
+// PlaceableCells returns the sorted ids of the cells that provide every
+// driver the mounts require. An app with no placeable cells can be rejected
+// at desire time.
+func (mounts VolumeMounts) PlaceableCells(cells CellVolumeDrivers) []string {
+	placeable := []string{}
+	for cellId, drivers := range cells {
+		if len(mounts.MissingDrivers(drivers)) == 0 {
+			placeable = append(placeable, cellId)
+		}
+	}
+	sort.Strings(placeable)
+	return placeable
+}
+
+func (r DesireAppRequestFromCC) ValidateVolumeMounts() error {
+	return observeValidation(DesireAppRequest, VolumeMounts(r.VolumeMounts).Validate())
+}
+
+func (r TaskRequestFromCC) ValidateVolumeMounts() error {
+	return observeValidation(TaskRequest, VolumeMounts(r.VolumeMounts).Validate())
+}
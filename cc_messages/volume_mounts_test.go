@@ -0,0 +1,86 @@
+package cc_messages_test
+
+import (
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VolumeMounts", func() {
+	var mounts cc_messages.VolumeMounts
+
+	BeforeEach(func() {
+		mounts = cc_messages.VolumeMounts{
+			{Driver: "nfsdriver", VolumeId: "volume-1", ContainerPath: "/data", Mode: models.BindMountMode_RW},
+			{Driver: "cephdriver", VolumeId: "volume-2", ContainerPath: "/var/config", Mode: models.BindMountMode_RO},
+			{Driver: "nfsdriver", VolumeId: "volume-3", ContainerPath: "/archive", Mode: models.BindMountMode_RO},
+		}
+	})
+
+	Describe("Validate", func() {
+		It("accepts valid mounts", func() {
+			Expect(mounts.Validate()).To(Succeed())
+		})
+
+		It("rejects relative container paths", func() {
+			mounts[1].ContainerPath = "var/config"
+			Expect(mounts.Validate()).To(Equal(cc_messages.VolumeMountError{Index: 1, Reason: "container path 'var/config' is not absolute"}))
+		})
+
+		It("rejects colliding container paths", func() {
+			mounts[2].ContainerPath = "/data/"
+			Expect(mounts.Validate()).To(Equal(cc_messages.VolumeMountError{Index: 2, Reason: "container path '/data' collides with mount 0"}))
+		})
+
+		It("rejects nested container paths", func() {
+			mounts[2].ContainerPath = "/data/archive"
+			Expect(mounts.Validate()).To(Equal(cc_messages.VolumeMountError{Index: 2, Reason: "container path '/data/archive' is inside mount 0"}))
+
+			mounts[2].ContainerPath = "/var"
+			Expect(mounts.Validate()).To(Equal(cc_messages.VolumeMountError{Index: 2, Reason: "container path '/var' contains mount 1"}))
+
+			mounts[2].ContainerPath = "/database"
+			Expect(mounts.Validate()).To(Succeed())
+		})
+
+		It("rejects invalid modes", func() {
+			mounts[0].Mode = models.BindMountMode(7)
+			Expect(mounts.Validate()).To(Equal(cc_messages.VolumeMountError{Index: 0, Reason: "invalid mode 7"}))
+		})
+
+		It("rejects empty drivers", func() {
+			mounts[0].Driver = ""
+			Expect(mounts.Validate()).To(Equal(cc_messages.VolumeMountError{Index: 0, Reason: "empty driver"}))
+		})
+
+		It("validates the mounts of desire and task requests", func() {
+			mounts[0].Driver = ""
+			Expect(cc_messages.DesireAppRequestFromCC{VolumeMounts: mounts}.ValidateVolumeMounts()).To(HaveOccurred())
+			Expect(cc_messages.TaskRequestFromCC{VolumeMounts: mounts}.ValidateVolumeMounts()).To(HaveOccurred())
+		})
+	})
+
+	Describe("driver matching", func() {
+		It("lists the distinct required drivers", func() {
+			Expect(mounts.RequiredDrivers()).To(Equal([]string{"cephdriver", "nfsdriver"}))
+		})
+
+		It("lists the drivers missing from a cell", func() {
+			Expect(mounts.MissingDrivers([]string{"nfsdriver"})).To(Equal([]string{"cephdriver"}))
+			Expect(mounts.MissingDrivers([]string{"nfsdriver", "cephdriver"})).To(BeEmpty())
+		})
+
+		It("finds the cells that provide every required driver", func() {
+			cells := cc_messages.CellVolumeDrivers{
+				"cell-a": {"nfsdriver", "cephdriver"},
+				"cell-b": {"nfsdriver"},
+				"cell-c": {"cephdriver", "nfsdriver", "smbdriver"},
+			}
+
+			Expect(mounts.PlaceableCells(cells)).To(Equal([]string{"cell-a", "cell-c"}))
+			Expect(cc_messages.VolumeMounts{}.PlaceableCells(cells)).To(Equal([]string{"cell-a", "cell-b", "cell-c"}))
+		})
+	})
+})
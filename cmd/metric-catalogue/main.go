@@ -0,0 +1,61 @@
+// Command metric-catalogue prints the metrics the packages of this repository
+// declare, and fails if any declarations conflict or nothing was declared.
+// Each package exports its declarations and is listed in declarations, so the
+// metric package does not depend on the packages that use it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages/instrumentation"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+)
+
+// declarations lists the metrics of every package that declares some.
+var declarations = [][]metric.Declaration{
+	instrumentation.Declarations(),
+}
+
+var format = flag.String(
+	"format",
+	"markdown",
+	"output format: markdown or json",
+)
+
+func main() {
+	flag.Parse()
+
+	catalogue := metric.NewCatalogue()
+	for _, packageDeclarations := range declarations {
+		for _, declaration := range packageDeclarations {
+			catalogue.Declare(declaration)
+		}
+	}
+
+	err := catalogue.Validate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if len(catalogue.Declarations()) == 0 {
+		fmt.Fprintln(os.Stderr, "no metrics declared")
+		os.Exit(1)
+	}
+
+	switch *format {
+	case "markdown":
+		err = catalogue.WriteMarkdown(os.Stdout)
+	case "json":
+		err = catalogue.WriteJSON(os.Stdout)
+	default:
+		err = fmt.Errorf("unknown format '%s'", *format)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
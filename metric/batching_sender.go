@@ -0,0 +1,204 @@
+package metric
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBatchFlushInterval = time.Second
+	DefaultBatchMaxSeries     = 1000
+)
+
+// BatchingSender buffers metrics and forwards them to another sender on
+// Flush. Counter deltas are summed and gauge-like values keep only their
+// last value, per name and tags. Timings, in nanos or seconds, are samples
+// rather than levels, so they are forwarded immediately instead. When the
+// buffer holds maxSeries series, the next new series flushes it.
+type BatchingSender struct {
+	sender        Sender
+	flushInterval time.Duration
+	maxSeries     int
+
+	// flushLock orders flushes, so that an older last value is never
+	// forwarded after a newer one; lock guards the buffer only and is never
+	// held while forwarding.
+	flushLock sync.Mutex
+	lock      sync.Mutex
+	counters  map[string]*batchedCounter
+	values    map[string]*batchedValue
+}
+
+type batchedCounter struct {
+	name  string
+	tags  map[string]string
+	delta uint64
+}
+
+type batchedValue struct {
+	name  string
+	tags  map[string]string
+	value float64
+	unit  string
+}
+
+func NewBatchingSender(sender Sender, flushInterval time.Duration, maxSeries int) *BatchingSender {
+	if flushInterval <= 0 {
+		flushInterval = DefaultBatchFlushInterval
+	}
+	if maxSeries <= 0 {
+		maxSeries = DefaultBatchMaxSeries
+	}
+
+	return &BatchingSender{
+		sender:        sender,
+		flushInterval: flushInterval,
+		maxSeries:     maxSeries,
+		counters:      map[string]*batchedCounter{},
+		values:        map[string]*batchedValue{},
+	}
+}
+
+func (s *BatchingSender) IncrementCounter(name string, tags map[string]string) error {
+	return s.AddToCounter(name, 1, tags)
+}
+
+func (s *BatchingSender) AddToCounter(name string, delta uint64, tags map[string]string) error {
+	key := seriesKey(name, tags)
+	add := func() {
+		if counter, found := s.counters[key]; found {
+			counter.delta += delta
+		} else {
+			s.counters[key] = &batchedCounter{name: name, tags: copyTags(tags), delta: delta}
+		}
+	}
+
+	return s.buffer(func() bool { return s.counters[key] != nil }, add)
+}
+
+func (s *BatchingSender) SendValue(name string, value float64, unit string, tags map[string]string) error {
+	if Unit(unit) == UnitNanos || Unit(unit) == UnitSeconds {
+		return s.sender.SendValue(name, value, unit, tags)
+	}
+
+	key := seriesKey(name, tags)
+	set := func() {
+		if batched, found := s.values[key]; found {
+			batched.value = value
+			batched.unit = unit
+		} else {
+			s.values[key] = &batchedValue{name: name, tags: copyTags(tags), value: value, unit: unit}
+		}
+	}
+
+	return s.buffer(func() bool { return s.values[key] != nil }, set)
+}
+
+// Flush forwards everything buffered, in series order, and returns the first
+// error from the underlying sender.
+func (s *BatchingSender) Flush() error {
+	s.flushLock.Lock()
+	defer s.flushLock.Unlock()
+
+	s.lock.Lock()
+	counters, values := s.counters, s.values
+	s.counters = map[string]*batchedCounter{}
+	s.values = map[string]*batchedValue{}
+	s.lock.Unlock()
+
+	return s.forward(counters, values)
+}
+
+// Run flushes every flush interval until signalled, then flushes once more.
+// It follows the ifrit.Runner signature so it can run in a process group.
+// A failed periodic flush does not stop Run; it returns the error from the
+// final flush or, when that succeeds, the last error from a periodic flush.
+func (s *BatchingSender) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	close(ready)
+
+	var lastErr error
+	for {
+		select {
+		case <-ticker.C:
+			err := s.Flush()
+			if err != nil {
+				lastErr = err
+			}
+		case <-signals:
+			err := s.Flush()
+			if err != nil {
+				return err
+			}
+			return lastErr
+		}
+	}
+}
+
+// buffer applies change with the lock held, first flushing outside the lock
+// when a new series would overflow the buffer. Senders racing past the flush
+// may overfill the buffer by one series each.
+func (s *BatchingSender) buffer(buffered func() bool, change func()) error {
+	s.lock.Lock()
+	if buffered() || len(s.counters)+len(s.values) < s.maxSeries {
+		change()
+		s.lock.Unlock()
+		return nil
+	}
+	s.lock.Unlock()
+
+	err := s.Flush()
+
+	s.lock.Lock()
+	change()
+	s.lock.Unlock()
+	return err
+}
+
+func (s *BatchingSender) forward(counters map[string]*batchedCounter, values map[string]*batchedValue) error {
+	var firstErr error
+	record := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	counterKeys := make([]string, 0, len(counters))
+	for key := range counters {
+		counterKeys = append(counterKeys, key)
+	}
+	sort.Strings(counterKeys)
+
+	for _, key := range counterKeys {
+		counter := counters[key]
+		record(s.sender.AddToCounter(counter.name, counter.delta, counter.tags))
+	}
+
+	valueKeys := make([]string, 0, len(values))
+	for key := range values {
+		valueKeys = append(valueKeys, key)
+	}
+	sort.Strings(valueKeys)
+
+	for _, key := range valueKeys {
+		value := values[key]
+		record(s.sender.SendValue(value.name, value.value, value.unit, value.tags))
+	}
+
+	return firstErr
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	if tags == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
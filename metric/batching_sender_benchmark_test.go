@@ -0,0 +1,66 @@
+package metric_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+)
+
+// The benchmarks compare the batching sender with the dropsonde sender, the
+// default and so the direct path in production.
+func dropsondeSender() metric.Sender {
+	return metric.DropsondeSender{}
+}
+
+func BenchmarkDirectIncrement(b *testing.B) {
+	previous := metric.SetSender(dropsondeSender())
+	defer metric.SetSender(previous)
+
+	counter := metric.Counter("Requests")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		counter.Increment()
+	}
+}
+
+func BenchmarkBatchedIncrement(b *testing.B) {
+	batcher := metric.NewBatchingSender(dropsondeSender(), time.Second, metric.DefaultBatchMaxSeries)
+	previous := metric.SetSender(batcher)
+	defer metric.SetSender(previous)
+
+	counter := metric.Counter("Requests")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		counter.Increment()
+	}
+	batcher.Flush()
+}
+
+func BenchmarkBatchedIncrementParallel(b *testing.B) {
+	batcher := metric.NewBatchingSender(dropsondeSender(), time.Second, metric.DefaultBatchMaxSeries)
+	previous := metric.SetSender(batcher)
+	defer metric.SetSender(previous)
+
+	counter := metric.Counter("Requests")
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			counter.Increment()
+		}
+	})
+	batcher.Flush()
+}
+
+func BenchmarkDirectIncrementParallel(b *testing.B) {
+	previous := metric.SetSender(dropsondeSender())
+	defer metric.SetSender(previous)
+
+	counter := metric.Counter("Requests")
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			counter.Increment()
+		}
+	})
+}
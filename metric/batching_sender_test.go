@@ -0,0 +1,234 @@
+package metric_test
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric/metrictest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type failingSender struct {
+	metric.Sender
+}
+
+func (failingSender) AddToCounter(string, uint64, map[string]string) error {
+	return errors.New("boom")
+}
+
+type failOnceSender struct {
+	metric.Sender
+	failed int32
+}
+
+func (s *failOnceSender) AddToCounter(name string, delta uint64, tags map[string]string) error {
+	if atomic.CompareAndSwapInt32(&s.failed, 0, 1) {
+		return errors.New("boom")
+	}
+	return s.Sender.AddToCounter(name, delta, tags)
+}
+
+type blockingSender struct {
+	metric.Sender
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (s blockingSender) AddToCounter(name string, delta uint64, tags map[string]string) error {
+	s.blocked <- struct{}{}
+	<-s.release
+	return s.Sender.AddToCounter(name, delta, tags)
+}
+
+var _ = Describe("BatchingSender", func() {
+	var (
+		fake    *metrictest.FakeSender
+		batcher *metric.BatchingSender
+	)
+
+	BeforeEach(func() {
+		fake = metrictest.NewFakeSender()
+		batcher = metric.NewBatchingSender(fake, time.Hour, 10)
+	})
+
+	It("buffers until flushed", func() {
+		batcher.IncrementCounter("Requests", nil)
+		batcher.SendValue("Memory", 256, "MiB", nil)
+		Expect(fake.Events()).To(BeEmpty())
+
+		Expect(batcher.Flush()).To(Succeed())
+		Expect(fake.Events()).To(HaveLen(2))
+
+		Expect(batcher.Flush()).To(Succeed())
+		Expect(fake.Events()).To(HaveLen(2))
+	})
+
+	It("sums counter deltas and keeps the last value, per series", func() {
+		tags := map[string]string{"domain": "cf-apps"}
+		batcher.IncrementCounter("Requests", nil)
+		batcher.AddToCounter("Requests", 4, nil)
+		batcher.IncrementCounter("Requests", tags)
+		batcher.SendValue("Memory", 128, "MiB", nil)
+		batcher.SendValue("Memory", 256, "MiB", nil)
+
+		Expect(batcher.Flush()).To(Succeed())
+		Expect(fake.Events()).To(Equal([]metrictest.Event{
+			{Type: metrictest.AddToCounterEvent, Name: "Requests", Value: 5},
+			{Type: metrictest.AddToCounterEvent, Name: "Requests", Value: 1, Tags: tags},
+			{Type: metrictest.SendValueEvent, Name: "Memory", Value: 256, Unit: "MiB"},
+		}))
+	})
+
+	It("forwards timings immediately instead of keeping the last one", func() {
+		batcher.SendValue("Latency", 1, "nanos", nil)
+		batcher.SendValue("Latency", 500, "nanos", nil)
+		batcher.SendValue("Latency", 2, "nanos", nil)
+		batcher.SendValue("Uptime", 3, "s", nil)
+
+		Expect(fake.Events()).To(Equal([]metrictest.Event{
+			{Type: metrictest.SendValueEvent, Name: "Latency", Value: 1, Unit: "nanos"},
+			{Type: metrictest.SendValueEvent, Name: "Latency", Value: 500, Unit: "nanos"},
+			{Type: metrictest.SendValueEvent, Name: "Latency", Value: 2, Unit: "nanos"},
+			{Type: metrictest.SendValueEvent, Name: "Uptime", Value: 3, Unit: "s"},
+		}))
+
+		Expect(batcher.Flush()).To(Succeed())
+		Expect(fake.Events()).To(HaveLen(4))
+	})
+
+	It("does not hold on to the caller's tags", func() {
+		tags := map[string]string{"domain": "cf-apps"}
+		batcher.IncrementCounter("Requests", tags)
+		tags["domain"] = "cf-tasks"
+
+		batcher.Flush()
+		Expect(fake.Events()[0].Tags).To(Equal(map[string]string{"domain": "cf-apps"}))
+	})
+
+	It("flushes when a new series would overflow the buffer", func() {
+		batcher = metric.NewBatchingSender(fake, time.Hour, 2)
+		batcher.IncrementCounter("A", nil)
+		batcher.IncrementCounter("B", nil)
+		batcher.IncrementCounter("B", nil)
+		Expect(fake.Events()).To(BeEmpty())
+
+		batcher.SendValue("C", 1, "Metric", nil)
+		Expect(fake.CounterTotal("A")).To(Equal(uint64(1)))
+		Expect(fake.CounterTotal("B")).To(Equal(uint64(2)))
+		Expect(fake.EventsNamed("C")).To(BeEmpty())
+	})
+
+	It("keeps buffering while an overflow flush is forwarding", func() {
+		blocking := blockingSender{Sender: fake, blocked: make(chan struct{}, 10), release: make(chan struct{})}
+		batcher = metric.NewBatchingSender(blocking, time.Hour, 2)
+		batcher.IncrementCounter("A", nil)
+		batcher.IncrementCounter("B", nil)
+
+		done := make(chan error)
+		go func() {
+			done <- batcher.IncrementCounter("C", nil)
+		}()
+		Eventually(blocking.blocked).Should(Receive())
+
+		batcher.SendValue("D", 1, "Metric", nil)
+		Expect(fake.Events()).To(BeEmpty())
+
+		close(blocking.release)
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(fake.CounterTotal("A")).To(Equal(uint64(1)))
+		Expect(fake.CounterTotal("B")).To(Equal(uint64(1)))
+
+		Expect(batcher.Flush()).To(Succeed())
+		Expect(fake.CounterTotal("C")).To(Equal(uint64(1)))
+		Expect(fake.EventsNamed("D")).To(HaveLen(1))
+	})
+
+	It("returns the first error from the underlying sender", func() {
+		batcher = metric.NewBatchingSender(failingSender{fake}, time.Hour, 10)
+		batcher.IncrementCounter("Requests", nil)
+		batcher.SendValue("Memory", 256, "MiB", nil)
+
+		Expect(batcher.Flush()).To(MatchError("boom"))
+		Expect(fake.EventsNamed("Memory")).To(HaveLen(1))
+	})
+
+	Describe("Run", func() {
+		var (
+			sender        metric.Sender
+			flushInterval time.Duration
+			signals       chan os.Signal
+			done          chan error
+		)
+
+		stop := func() {
+			signals <- os.Interrupt
+			Eventually(done).Should(Receive(BeNil()))
+			signals = nil
+		}
+
+		BeforeEach(func() {
+			sender = fake
+			flushInterval = 10 * time.Millisecond
+		})
+
+		JustBeforeEach(func() {
+			batcher = metric.NewBatchingSender(sender, flushInterval, 10)
+			signals = make(chan os.Signal, 1)
+			ready := make(chan struct{})
+			done = make(chan error, 1)
+
+			go func() {
+				done <- batcher.Run(signals, ready)
+			}()
+			Eventually(ready).Should(BeClosed())
+		})
+
+		AfterEach(func() {
+			if signals != nil {
+				stop()
+			}
+		})
+
+		It("flushes periodically", func() {
+			batcher.IncrementCounter("Requests", nil)
+			Eventually(fake.Events).Should(HaveLen(1))
+		})
+
+		Context("when a periodic flush fails", func() {
+			BeforeEach(func() {
+				sender = &failOnceSender{Sender: fake}
+			})
+
+			It("keeps flushing and returns the error on shutdown", func() {
+				batcher.IncrementCounter("Requests", nil)
+				Eventually(func() int32 { return atomic.LoadInt32(&sender.(*failOnceSender).failed) }).Should(Equal(int32(1)))
+
+				batcher.IncrementCounter("Requests", nil)
+				Eventually(fake.Events).Should(HaveLen(1))
+
+				signals <- os.Interrupt
+				Eventually(done).Should(Receive(MatchError("boom")))
+				signals = nil
+			})
+		})
+
+		Context("when signalled before the flush interval", func() {
+			BeforeEach(func() {
+				flushInterval = time.Hour
+			})
+
+			It("flushes on shutdown", func() {
+				batcher.IncrementCounter("Requests", nil)
+				Expect(fake.Events()).To(BeEmpty())
+
+				stop()
+				Expect(fake.CounterTotal("Requests")).To(Equal(uint64(1)))
+			})
+		})
+	})
+})
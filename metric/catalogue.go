@@ -0,0 +1,226 @@
+package metric
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type MetricType string
+
+const (
+	CounterType   MetricType = "counter"
+	ValueType     MetricType = "value"
+	GaugeType     MetricType = "gauge"
+	HistogramType MetricType = "histogram"
+)
+
+// Declaration describes a metric once, for the catalogue. Counters have no
+// unit.
+type Declaration struct {
+	Name string     `json:"name"`
+	Type MetricType `json:"type"`
+	Unit Unit       `json:"unit,omitempty"`
+	Help string     `json:"help"`
+}
+
+// CatalogueConflict lists the differing declarations of a single name.
+type CatalogueConflict struct {
+	Name         string
+	Declarations []Declaration
+}
+
+type CatalogueConflictError struct {
+	Conflicts []CatalogueConflict
+}
+
+func (err CatalogueConflictError) Error() string {
+	messages := make([]string, 0, len(err.Conflicts))
+	for _, conflict := range err.Conflicts {
+		kinds := make([]string, 0, len(conflict.Declarations))
+		for _, declaration := range conflict.Declarations {
+			kinds = append(kinds, fmt.Sprintf("%s %s", declaration.Type, declaration.Unit))
+		}
+		messages = append(messages, fmt.Sprintf("%s declared as %s", conflict.Name, strings.Join(kinds, ", ")))
+	}
+	return "conflicting metric declarations: " + strings.Join(messages, "; ")
+}
+
+// Catalogue collects the metrics components declare. Declaring a name again
+// with the same type and unit is allowed and keeps the first help text;
+// declaring it with a different type or unit is a conflict reported by
+// Validate.
+type Catalogue struct {
+	lock         sync.Mutex
+	declarations map[string][]Declaration
+}
+
+func NewCatalogue() *Catalogue {
+	return &Catalogue{declarations: map[string][]Declaration{}}
+}
+
+// DefaultCatalogue holds the declarations made through the package-level
+// Declare functions.
+var DefaultCatalogue = NewCatalogue()
+
+func (c *Catalogue) Declare(declaration Declaration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, existing := range c.declarations[declaration.Name] {
+		if existing.Type == declaration.Type && existing.Unit == declaration.Unit {
+			return
+		}
+	}
+	c.declarations[declaration.Name] = append(c.declarations[declaration.Name], declaration)
+}
+
+// Validate returns a CatalogueConflictError if any name was declared with
+// different types or units.
+func (c *Catalogue) Validate() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	conflicts := []CatalogueConflict{}
+	for _, name := range c.sortedNames() {
+		declarations := c.declarations[name]
+		if len(declarations) > 1 {
+			conflicts = append(conflicts, CatalogueConflict{
+				Name:         name,
+				Declarations: append([]Declaration{}, declarations...),
+			})
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return CatalogueConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// Declarations returns every declaration sorted by name, conflicting ones in
+// the order they were declared.
+func (c *Catalogue) Declarations() []Declaration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	declarations := []Declaration{}
+	for _, name := range c.sortedNames() {
+		declarations = append(declarations, c.declarations[name]...)
+	}
+	return declarations
+}
+
+func (c *Catalogue) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c.Declarations())
+}
+
+func (c *Catalogue) WriteMarkdown(w io.Writer) error {
+	_, err := fmt.Fprintln(w, "| Name | Type | Unit | Description |\n| --- | --- | --- | --- |")
+	if err != nil {
+		return err
+	}
+
+	for _, declaration := range c.Declarations() {
+		_, err = fmt.Fprintf(w, "| `%s` | %s | %s | %s |\n",
+			declaration.Name,
+			declaration.Type,
+			declaration.Unit,
+			strings.Replace(declaration.Help, "|", `\|`, -1),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Catalogue) sortedNames() []string {
+	names := make([]string, 0, len(c.declarations))
+	for name := range c.declarations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Declare(declaration Declaration) {
+	DefaultCatalogue.Declare(declaration)
+}
+
+func DeclareCounter(name, help string) Counter {
+	Declare(Declaration{Name: name, Type: CounterType, Help: help})
+	return Counter(name)
+}
+
+func DeclareDuration(name, help string) Duration {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: UnitNanos, Help: help})
+	return Duration(name)
+}
+
+func DeclareSeconds(name, help string) Seconds {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: UnitSeconds, Help: help})
+	return Seconds(name)
+}
+
+func DeclareBytes(name, help string) Bytes {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: UnitMebibytes, Help: help})
+	return Bytes(name)
+}
+
+func DeclarePercentage(name, help string) Percentage {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: UnitPercent, Help: help})
+	return Percentage(name)
+}
+
+func DeclareValue(name string, unit Unit, help string) Value {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: unit, Help: help})
+	return NewValue(name, unit)
+}
+
+func DeclareMebibytes(name, help string) Mebibytes {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: UnitMebibytes, Help: help})
+	return Mebibytes(name)
+}
+
+func DeclareMetric(name, help string) Metric {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: UnitMetric, Help: help})
+	return Metric(name)
+}
+
+func DeclareRequests(name, help string) Requests {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: UnitRequests, Help: help})
+	return Requests(name)
+}
+
+func DeclareBytesPerSecond(name, help string) BytesPerSecond {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: UnitBytesPerSecond, Help: help})
+	return BytesPerSecond(name)
+}
+
+func DeclareRequestsPerSecond(name, help string) RequestsPerSecond {
+	Declare(Declaration{Name: name, Type: ValueType, Unit: UnitRequestsPerSecond, Help: help})
+	return RequestsPerSecond(name)
+}
+
+func DeclareResultCounter(name, help string) ResultCounter {
+	counter := ResultCounter(name)
+	Declare(Declaration{Name: string(counter.SuccessCounter()), Type: CounterType, Help: help + " (successes)"})
+	Declare(Declaration{Name: string(counter.ErrorCounter()), Type: CounterType, Help: help + " (errors)"})
+	return counter
+}
+
+func DeclareGauge(name string, unit Unit, help string) *Gauge {
+	Declare(Declaration{Name: name, Type: GaugeType, Unit: unit, Help: help})
+	return NewGaugeWithUnit(name, unit)
+}
+
+func DeclareHistogram(name string, unit Unit, buckets []float64, help string) *Histogram {
+	Declare(Declaration{Name: name, Type: HistogramType, Unit: unit, Help: help})
+	return NewHistogram(name, unit, buckets)
+}
@@ -0,0 +1,87 @@
+package metric_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Catalogue", func() {
+	var catalogue *metric.Catalogue
+
+	BeforeEach(func() {
+		catalogue = metric.NewCatalogue()
+		catalogue.Declare(metric.Declaration{Name: "RequestLatency", Type: metric.ValueType, Unit: metric.UnitNanos, Help: "Time to handle a request"})
+		catalogue.Declare(metric.Declaration{Name: "Desires", Type: metric.CounterType, Help: "Desire requests | received"})
+	})
+
+	It("lists the declarations by name", func() {
+		Expect(catalogue.Validate()).To(Succeed())
+		Expect(catalogue.Declarations()).To(Equal([]metric.Declaration{
+			{Name: "Desires", Type: metric.CounterType, Help: "Desire requests | received"},
+			{Name: "RequestLatency", Type: metric.ValueType, Unit: metric.UnitNanos, Help: "Time to handle a request"},
+		}))
+	})
+
+	It("allows a name to be declared again with the same type and unit", func() {
+		catalogue.Declare(metric.Declaration{Name: "RequestLatency", Type: metric.ValueType, Unit: metric.UnitNanos, Help: "Other help"})
+
+		Expect(catalogue.Validate()).To(Succeed())
+		Expect(catalogue.Declarations()).To(HaveLen(2))
+		Expect(catalogue.Declarations()[1].Help).To(Equal("Time to handle a request"))
+	})
+
+	It("reports names declared with different units or types", func() {
+		catalogue.Declare(metric.Declaration{Name: "RequestLatency", Type: metric.ValueType, Unit: metric.UnitMetric})
+		catalogue.Declare(metric.Declaration{Name: "Desires", Type: metric.GaugeType, Unit: metric.UnitMetric})
+
+		err := catalogue.Validate()
+		Expect(err).To(BeAssignableToTypeOf(metric.CatalogueConflictError{}))
+
+		conflicts := err.(metric.CatalogueConflictError).Conflicts
+		Expect(conflicts).To(HaveLen(2))
+		Expect(conflicts[0].Name).To(Equal("Desires"))
+		Expect(conflicts[1].Name).To(Equal("RequestLatency"))
+		Expect(conflicts[1].Declarations[1].Unit).To(Equal(metric.UnitMetric))
+		Expect(err.Error()).To(ContainSubstring("RequestLatency declared as value nanos, value Metric"))
+	})
+
+	It("writes markdown", func() {
+		buffer := &bytes.Buffer{}
+		Expect(catalogue.WriteMarkdown(buffer)).To(Succeed())
+		Expect(buffer.String()).To(Equal(
+			"| Name | Type | Unit | Description |\n" +
+				"| --- | --- | --- | --- |\n" +
+				"| `Desires` | counter |  | Desire requests \\| received |\n" +
+				"| `RequestLatency` | value | nanos | Time to handle a request |\n",
+		))
+	})
+
+	It("writes json", func() {
+		buffer := &bytes.Buffer{}
+		Expect(catalogue.WriteJSON(buffer)).To(Succeed())
+
+		declarations := []metric.Declaration{}
+		Expect(json.Unmarshal(buffer.Bytes(), &declarations)).To(Succeed())
+		Expect(declarations).To(Equal(catalogue.Declarations()))
+	})
+
+	Describe("the package-level declarations", func() {
+		It("declare into the default catalogue and return the metric", func() {
+			Expect(metric.DeclareDuration("CatalogueTestLatency", "latency")).To(Equal(metric.Duration("CatalogueTestLatency")))
+			counter := metric.DeclareResultCounter("CatalogueTestFetch", "fetches")
+			Expect(counter).To(Equal(metric.ResultCounter("CatalogueTestFetch")))
+
+			Expect(metric.DefaultCatalogue.Declarations()).To(ContainElement(
+				metric.Declaration{Name: "CatalogueTestLatency", Type: metric.ValueType, Unit: metric.UnitNanos, Help: "latency"},
+			))
+			Expect(metric.DefaultCatalogue.Declarations()).To(ContainElement(
+				metric.Declaration{Name: "CatalogueTestFetchError", Type: metric.CounterType, Help: "fetches (errors)"},
+			))
+		})
+	})
+})
@@ -0,0 +1,43 @@
+package metric
+
+import "github.com/cloudfoundry/dropsonde/metrics"
+
+// DropsondeSender emits through the global dropsonde metrics functions. It is
+// the default sender.
+type DropsondeSender struct{}
+
+func (DropsondeSender) IncrementCounter(name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return metrics.IncrementCounter(name)
+	}
+
+	counter := metrics.Counter(name)
+	for key, tagValue := range tags {
+		counter = counter.SetTag(key, tagValue)
+	}
+	return counter.Increment()
+}
+
+func (DropsondeSender) AddToCounter(name string, delta uint64, tags map[string]string) error {
+	if len(tags) == 0 {
+		return metrics.AddToCounter(name, delta)
+	}
+
+	counter := metrics.Counter(name)
+	for key, tagValue := range tags {
+		counter = counter.SetTag(key, tagValue)
+	}
+	return counter.Add(delta)
+}
+
+func (DropsondeSender) SendValue(name string, value float64, unit string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return metrics.SendValue(name, value, unit)
+	}
+
+	chainer := metrics.Value(name, value, unit)
+	for key, tagValue := range tags {
+		chainer = chainer.SetTag(key, tagValue)
+	}
+	return chainer.Send()
+}
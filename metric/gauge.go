@@ -0,0 +1,66 @@
+package metric
+
+import "sync"
+
+// Gauge holds a current value that can be set, incremented or decremented.
+// Every change sends the new value.
+type Gauge struct {
+	name   string
+	unit   Unit
+	tags   Tags
+	sender Sender
+
+	lock  sync.Mutex
+	value float64
+}
+
+func NewGauge(name string) *Gauge {
+	return NewGaugeWithUnit(name, UnitMetric)
+}
+
+func NewGaugeWithUnit(name string, unit Unit) *Gauge {
+	return &Gauge{name: name, unit: unit}
+}
+
+// WithTags returns a new gauge, starting at zero, for the tagged series.
+func (g *Gauge) WithTags(tags map[string]string) *Gauge {
+	return &Gauge{name: g.name, unit: g.unit, tags: g.tags.Merge(tags), sender: g.sender}
+}
+
+// WithSender returns a new gauge, starting at zero, that sends through
+// sender instead of the package-level sender.
+func (g *Gauge) WithSender(sender Sender) *Gauge {
+	return &Gauge{name: g.name, unit: g.unit, tags: g.tags, sender: sender}
+}
+
+func (g *Gauge) Set(value float64) error {
+	g.lock.Lock()
+	g.value = value
+	g.lock.Unlock()
+
+	return sendValue(g.sender, g.name, value, g.unit, g.tags)
+}
+
+func (g *Gauge) Add(delta float64) error {
+	g.lock.Lock()
+	g.value += delta
+	value := g.value
+	g.lock.Unlock()
+
+	return sendValue(g.sender, g.name, value, g.unit, g.tags)
+}
+
+func (g *Gauge) Inc() error {
+	return g.Add(1)
+}
+
+func (g *Gauge) Dec() error {
+	return g.Add(-1)
+}
+
+func (g *Gauge) Value() float64 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.value
+}
@@ -0,0 +1,50 @@
+package metric_test
+
+import (
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gauge", func() {
+	var (
+		sender   *metric.MemorySender
+		previous metric.Sender
+	)
+
+	BeforeEach(func() {
+		sender = metric.NewMemorySender()
+		previous = metric.SetSender(sender)
+	})
+
+	AfterEach(func() {
+		metric.SetSender(previous)
+	})
+
+	It("sends the value after every change", func() {
+		gauge := metric.NewGauge("InFlight")
+
+		Expect(gauge.Set(5)).To(Succeed())
+		Expect(valueOf(sender, "InFlight", nil)).To(Equal(metric.MemoryValue{Value: 5, Unit: "Metric"}))
+
+		Expect(gauge.Inc()).To(Succeed())
+		Expect(gauge.Inc()).To(Succeed())
+		Expect(gauge.Dec()).To(Succeed())
+		Expect(valueOf(sender, "InFlight", nil).Value).To(Equal(6.0))
+
+		Expect(gauge.Add(-2.5)).To(Succeed())
+		Expect(gauge.Value()).To(Equal(3.5))
+	})
+
+	It("keeps tagged gauges separate", func() {
+		gauge := metric.NewGaugeWithUnit("Memory", "MiB")
+		tagged := gauge.WithTags(map[string]string{"cell": "cell-1"})
+
+		Expect(gauge.Set(10)).To(Succeed())
+		Expect(tagged.Inc()).To(Succeed())
+
+		Expect(valueOf(sender, "Memory", nil)).To(Equal(metric.MemoryValue{Value: 10, Unit: "MiB"}))
+		Expect(valueOf(sender, "Memory", map[string]string{"cell": "cell-1"})).To(Equal(metric.MemoryValue{Value: 1, Unit: "MiB"}))
+	})
+})
@@ -0,0 +1,199 @@
+package metric
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultDurationBuckets suit request latencies, in nanoseconds.
+var DefaultDurationBuckets = []float64{
+	float64(time.Millisecond),
+	float64(5 * time.Millisecond),
+	float64(10 * time.Millisecond),
+	float64(50 * time.Millisecond),
+	float64(100 * time.Millisecond),
+	float64(500 * time.Millisecond),
+	float64(time.Second),
+	float64(5 * time.Second),
+	float64(10 * time.Second),
+	float64(30 * time.Second),
+}
+
+// EmittedQuantiles are the percentiles Histogram.Emit sends.
+var EmittedQuantiles = map[string]float64{
+	"p50": 0.5,
+	"p95": 0.95,
+	"p99": 0.99,
+}
+
+// Histogram counts observations into buckets so that percentiles can be
+// estimated locally instead of from every raw value.
+type Histogram struct {
+	name    string
+	unit    Unit
+	tags    Tags
+	sender  Sender
+	buckets []float64
+
+	lock     sync.Mutex
+	snapshot HistogramSnapshot
+}
+
+// HistogramSnapshot holds the observations per bucket. Counts has one more
+// entry than Buckets, for the observations above the last bound.
+type HistogramSnapshot struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+	Min     float64
+	Max     float64
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds.
+func NewHistogram(name string, unit Unit, buckets []float64) *Histogram {
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	h := &Histogram{name: name, unit: unit, buckets: sorted}
+	h.snapshot = h.emptySnapshot()
+	return h
+}
+
+func NewDurationHistogram(name string) *Histogram {
+	return NewHistogram(name, UnitNanos, DefaultDurationBuckets)
+}
+
+// WithTags returns a new, empty histogram for the tagged series.
+func (h *Histogram) WithTags(tags map[string]string) *Histogram {
+	tagged := NewHistogram(h.name, h.unit, h.buckets)
+	tagged.tags = h.tags.Merge(tags)
+	tagged.sender = h.sender
+	return tagged
+}
+
+// WithSender returns a new, empty histogram that emits through sender
+// instead of the package-level sender.
+func (h *Histogram) WithSender(sender Sender) *Histogram {
+	bound := NewHistogram(h.name, h.unit, h.buckets)
+	bound.tags = h.tags
+	bound.sender = sender
+	return bound
+}
+
+func (h *Histogram) Observe(value float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	i := sort.SearchFloat64s(h.buckets, value)
+	h.snapshot.Counts[i]++
+	h.snapshot.Count++
+	h.snapshot.Sum += value
+	if h.snapshot.Count == 1 || value < h.snapshot.Min {
+		h.snapshot.Min = value
+	}
+	if h.snapshot.Count == 1 || value > h.snapshot.Max {
+		h.snapshot.Max = value
+	}
+}
+
+func (h *Histogram) ObserveDuration(duration time.Duration) {
+	h.Observe(float64(duration))
+}
+
+// StartTimer returns a function that observes the time elapsed since
+// StartTimer was called.
+func (h *Histogram) StartTimer() func() {
+	start := time.Now()
+	return func() {
+		h.ObserveDuration(time.Since(start))
+	}
+}
+
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.snapshot.copy()
+}
+
+// Emit sends the count, the maximum and the EmittedQuantiles of the
+// observations since the previous Emit, then starts a new interval. Nothing
+// is sent for an interval without observations.
+func (h *Histogram) Emit() error {
+	h.lock.Lock()
+	snapshot := h.snapshot
+	h.snapshot = h.emptySnapshot()
+	h.lock.Unlock()
+
+	if snapshot.Count == 0 {
+		return nil
+	}
+
+	err := sendValue(h.sender, h.name+".count", float64(snapshot.Count), UnitMetric, h.tags)
+	if err != nil {
+		return err
+	}
+
+	err = sendValue(h.sender, h.name+".max", snapshot.Max, h.unit, h.tags)
+	if err != nil {
+		return err
+	}
+
+	for suffix, q := range EmittedQuantiles {
+		err = sendValue(h.sender, h.name+"."+suffix, snapshot.Quantile(q), h.unit, h.tags)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *Histogram) emptySnapshot() HistogramSnapshot {
+	return HistogramSnapshot{
+		Buckets: h.buckets,
+		Counts:  make([]uint64, len(h.buckets)+1),
+	}
+}
+
+func (s HistogramSnapshot) copy() HistogramSnapshot {
+	s.Counts = append([]uint64{}, s.Counts...)
+	return s
+}
+
+// Quantile estimates the q-th quantile, 0 <= q <= 1, by interpolating
+// linearly within the bucket it falls into, narrowed to the observed Min and
+// Max.
+func (s HistogramSnapshot) Quantile(q float64) float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+
+	rank := q * float64(s.Count)
+	var cumulative uint64
+	for i, count := range s.Counts {
+		if count == 0 || float64(cumulative+count) < rank {
+			cumulative += count
+			continue
+		}
+
+		lower := s.Min
+		if i > 0 && s.Buckets[i-1] > lower {
+			lower = s.Buckets[i-1]
+		}
+		upper := s.Max
+		if i < len(s.Buckets) && s.Buckets[i] < upper {
+			upper = s.Buckets[i]
+		}
+		if upper < lower {
+			upper = lower
+		}
+
+		return lower + (upper-lower)*(rank-float64(cumulative))/float64(count)
+	}
+
+	return s.Max
+}
@@ -0,0 +1,99 @@
+package metric_test
+
+import (
+	"math"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Histogram", func() {
+	var (
+		sender    *metric.MemorySender
+		previous  metric.Sender
+		histogram *metric.Histogram
+	)
+
+	BeforeEach(func() {
+		sender = metric.NewMemorySender()
+		previous = metric.SetSender(sender)
+		histogram = metric.NewHistogram("Latency", "nanos", []float64{100, 10, 1000})
+	})
+
+	AfterEach(func() {
+		metric.SetSender(previous)
+	})
+
+	It("counts observations into the buckets", func() {
+		for _, value := range []float64{1, 10, 11, 100, 500, 5000} {
+			histogram.Observe(value)
+		}
+
+		snapshot := histogram.Snapshot()
+		Expect(snapshot.Buckets).To(Equal([]float64{10, 100, 1000}))
+		Expect(snapshot.Counts).To(Equal([]uint64{2, 2, 1, 1}))
+		Expect(snapshot.Count).To(Equal(uint64(6)))
+		Expect(snapshot.Sum).To(Equal(5622.0))
+		Expect(snapshot.Min).To(Equal(1.0))
+		Expect(snapshot.Max).To(Equal(5000.0))
+	})
+
+	Describe("Quantile", func() {
+		It("interpolates within the bucket", func() {
+			for i := 0; i < 100; i++ {
+				histogram.Observe(50)
+			}
+
+			snapshot := histogram.Snapshot()
+			Expect(snapshot.Quantile(0.5)).To(Equal(50.0))
+			Expect(snapshot.Quantile(1)).To(Equal(50.0))
+		})
+
+		It("is capped by the maximum observation above the last bucket", func() {
+			histogram.Observe(2000)
+			Expect(histogram.Snapshot().Quantile(0.99)).To(BeNumerically("<=", 2000))
+			Expect(histogram.Snapshot().Quantile(0.99)).To(BeNumerically(">", 1000))
+		})
+
+		It("is NaN without observations", func() {
+			Expect(math.IsNaN(histogram.Snapshot().Quantile(0.5))).To(BeTrue())
+		})
+	})
+
+	Describe("Emit", func() {
+		It("sends the count, maximum and percentiles and starts a new interval", func() {
+			for i := 1; i <= 100; i++ {
+				histogram.Observe(float64(i * 10))
+			}
+
+			Expect(histogram.Emit()).To(Succeed())
+
+			Expect(valueOf(sender, "Latency.count", nil)).To(Equal(metric.MemoryValue{Value: 100, Unit: "Metric"}))
+			Expect(valueOf(sender, "Latency.max", nil)).To(Equal(metric.MemoryValue{Value: 1000, Unit: "nanos"}))
+			Expect(valueOf(sender, "Latency.p50", nil).Value).To(BeNumerically("~", 500, 10))
+			Expect(valueOf(sender, "Latency.p99", nil).Value).To(BeNumerically("~", 990, 10))
+
+			Expect(histogram.Snapshot().Count).To(BeZero())
+		})
+
+		It("sends nothing without observations", func() {
+			Expect(histogram.Emit()).To(Succeed())
+			_, found := sender.Value("Latency.count", nil)
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	It("times durations", func() {
+		durations := metric.NewDurationHistogram("RequestLatency")
+		stop := durations.StartTimer()
+		time.Sleep(time.Millisecond)
+		stop()
+
+		snapshot := durations.Snapshot()
+		Expect(snapshot.Count).To(Equal(uint64(1)))
+		Expect(snapshot.Sum).To(BeNumerically(">=", float64(time.Millisecond)))
+	})
+})
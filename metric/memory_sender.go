@@ -0,0 +1,66 @@
+package metric
+
+import "sync"
+
+type MemoryValue struct {
+	Value float64
+	Unit  string
+}
+
+// MemorySender keeps the running total of every counter and the last value
+// of every other metric, per name and tags.
+type MemorySender struct {
+	lock     sync.Mutex
+	counters map[string]uint64
+	values   map[string]MemoryValue
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{
+		counters: map[string]uint64{},
+		values:   map[string]MemoryValue{},
+	}
+}
+
+func (s *MemorySender) IncrementCounter(name string, tags map[string]string) error {
+	return s.AddToCounter(name, 1, tags)
+}
+
+func (s *MemorySender) AddToCounter(name string, delta uint64, tags map[string]string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.counters[seriesKey(name, tags)] += delta
+	return nil
+}
+
+func (s *MemorySender) SendValue(name string, value float64, unit string, tags map[string]string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.values[seriesKey(name, tags)] = MemoryValue{Value: value, Unit: unit}
+	return nil
+}
+
+func (s *MemorySender) Counter(name string, tags map[string]string) uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.counters[seriesKey(name, tags)]
+}
+
+func (s *MemorySender) Value(name string, tags map[string]string) (MemoryValue, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	value, ok := s.values[seriesKey(name, tags)]
+	return value, ok
+}
+
+func (s *MemorySender) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.counters = map[string]uint64{}
+	s.values = map[string]MemoryValue{}
+}
@@ -1,53 +1,49 @@
 package metric
 
-import (
-	"time"
-
-	"github.com/cloudfoundry/dropsonde/metrics"
-)
+import "time"
 
 type Counter string
 
 func (c Counter) Increment() {
-	metrics.IncrementCounter(string(c))
+	incrementCounter(nil, string(c), nil)
 }
 
 func (c Counter) Add(i uint64) {
-	metrics.AddToCounter(string(c), i)
+	addToCounter(nil, string(c), i, nil)
 }
 
 type Duration string
 
 func (name Duration) Send(duration time.Duration) error {
-	return metrics.SendValue(string(name), float64(duration), "nanos")
+	return sendValue(nil, string(name), float64(duration), UnitNanos, nil)
 }
 
 type Mebibytes string
 
 func (name Mebibytes) Send(mebibytes int) error {
-	return metrics.SendValue(string(name), float64(mebibytes), "MiB")
+	return sendValue(nil, string(name), float64(mebibytes), UnitMebibytes, nil)
 }
 
 type Metric string
 
 func (name Metric) Send(value int) error {
-	return metrics.SendValue(string(name), float64(value), "Metric")
+	return sendValue(nil, string(name), float64(value), UnitMetric, nil)
 }
 
 type Requests string
 
 func (name Requests) Send(value int) error {
-	return metrics.SendValue(string(name), float64(value), "Req")
+	return sendValue(nil, string(name), float64(value), UnitRequests, nil)
 }
 
 type BytesPerSecond string
 
 func (name BytesPerSecond) Send(value float64) error {
-	return metrics.SendValue(string(name), value, "B/s")
+	return sendValue(nil, string(name), value, UnitBytesPerSecond, nil)
 }
 
 type RequestsPerSecond string
 
 func (name RequestsPerSecond) Send(value float64) error {
-	return metrics.SendValue(string(name), value, "Req/s")
+	return sendValue(nil, string(name), value, UnitRequestsPerSecond, nil)
 }
@@ -0,0 +1,117 @@
+package metrictest
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+)
+
+type EventType string
+
+const (
+	IncrementCounterEvent EventType = "IncrementCounter"
+	AddToCounterEvent     EventType = "AddToCounter"
+	SendValueEvent        EventType = "SendValue"
+)
+
+// Event is a single call made to the sender. Value holds the delta for
+// counters, which is 1 for IncrementCounter.
+type Event struct {
+	Type  EventType
+	Name  string
+	Value float64
+	Unit  string
+	Tags  map[string]string
+}
+
+// FakeSender records every call, in order. It is safe for concurrent use.
+type FakeSender struct {
+	lock   sync.Mutex
+	events []Event
+}
+
+func NewFakeSender() *FakeSender {
+	return &FakeSender{}
+}
+
+// Install makes a new FakeSender the package-level metric sender and returns
+// it with a function that restores the previous sender. Every metric not
+// bound to a sender reports to it, so tests using Install must not run in
+// parallel; prefer binding the metrics under test to a NewFakeSender with
+// WithSender.
+func Install() (*FakeSender, func()) {
+	sender := NewFakeSender()
+	previous := metric.SetSender(sender)
+	return sender, func() {
+		metric.SetSender(previous)
+	}
+}
+
+func (s *FakeSender) IncrementCounter(name string, tags map[string]string) error {
+	s.record(Event{Type: IncrementCounterEvent, Name: name, Value: 1, Tags: copyTags(tags)})
+	return nil
+}
+
+func (s *FakeSender) AddToCounter(name string, delta uint64, tags map[string]string) error {
+	s.record(Event{Type: AddToCounterEvent, Name: name, Value: float64(delta), Tags: copyTags(tags)})
+	return nil
+}
+
+func (s *FakeSender) SendValue(name string, value float64, unit string, tags map[string]string) error {
+	s.record(Event{Type: SendValueEvent, Name: name, Value: value, Unit: unit, Tags: copyTags(tags)})
+	return nil
+}
+
+func (s *FakeSender) Events() []Event {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]Event{}, s.events...)
+}
+
+// EventsNamed returns the events for the named metric, in order.
+func (s *FakeSender) EventsNamed(name string) []Event {
+	events := []Event{}
+	for _, event := range s.Events() {
+		if event.Name == name {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// CounterTotal sums the increments and additions to the named counter.
+func (s *FakeSender) CounterTotal(name string) uint64 {
+	var total uint64
+	for _, event := range s.EventsNamed(name) {
+		if event.Type != SendValueEvent {
+			total += uint64(event.Value)
+		}
+	}
+	return total
+}
+
+func (s *FakeSender) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.events = nil
+}
+
+func (s *FakeSender) record(event Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.events = append(s.events, event)
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	if tags == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
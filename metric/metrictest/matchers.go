@@ -0,0 +1,141 @@
+package metrictest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// HaveIncrementedCounter succeeds if the FakeSender recorded an increment of,
+// or an addition to, the named counter.
+func HaveIncrementedCounter(name string) types.GomegaMatcher {
+	return &eventMatcher{
+		description: fmt.Sprintf("to have incremented counter %q", name),
+		matches: func(event Event) (bool, error) {
+			return event.Name == name && event.Type != SendValueEvent, nil
+		},
+	}
+}
+
+// HaveAddedToCounter succeeds if the named counter received the delta, which
+// may be a uint64 or a matcher.
+func HaveAddedToCounter(name string, delta interface{}) types.GomegaMatcher {
+	return &eventMatcher{
+		description: fmt.Sprintf("to have added %s to counter %q", describe(delta), name),
+		matches: func(event Event) (bool, error) {
+			if event.Name != name || event.Type == SendValueEvent {
+				return false, nil
+			}
+			return matchValue(delta, uint64(event.Value))
+		},
+	}
+}
+
+// HaveSentValue succeeds if the named metric was sent in the unit with a
+// float64 value matching value, which may be a float64 or a matcher.
+func HaveSentValue(name string, value interface{}, unit string) types.GomegaMatcher {
+	return &eventMatcher{
+		description: fmt.Sprintf("to have sent %q with value %s %s", name, describe(value), unit),
+		matches: func(event Event) (bool, error) {
+			if event.Name != name || event.Type != SendValueEvent || event.Unit != unit {
+				return false, nil
+			}
+			return matchValue(value, event.Value)
+		},
+	}
+}
+
+// HaveSentDuration succeeds if the named duration was sent with a
+// time.Duration matching duration, which may be a time.Duration or a
+// matcher. Without a duration any value matches.
+func HaveSentDuration(name string, duration ...interface{}) types.GomegaMatcher {
+	expected := interface{}(gomega.BeAssignableToTypeOf(time.Duration(0)))
+	if len(duration) > 0 {
+		expected = duration[0]
+	}
+
+	return &eventMatcher{
+		description: fmt.Sprintf("to have sent duration %q with value %s", name, describe(expected)),
+		matches: func(event Event) (bool, error) {
+			if event.Name != name || event.Type != SendValueEvent || event.Unit != string(metric.UnitNanos) {
+				return false, nil
+			}
+			return matchValue(expected, time.Duration(event.Value))
+		},
+	}
+}
+
+// HaveSentMebibytes succeeds if the named metric was sent in MiB with a
+// float64 value matching mebibytes, which may be an int, a float64 or a
+// matcher. Fractional values are compared exactly rather than truncated.
+func HaveSentMebibytes(name string, mebibytes interface{}) types.GomegaMatcher {
+	if whole, ok := mebibytes.(int); ok {
+		mebibytes = float64(whole)
+	}
+
+	return &eventMatcher{
+		description: fmt.Sprintf("to have sent %q with value %s MiB", name, describe(mebibytes)),
+		matches: func(event Event) (bool, error) {
+			if event.Name != name || event.Type != SendValueEvent || event.Unit != string(metric.UnitMebibytes) {
+				return false, nil
+			}
+			return matchValue(mebibytes, event.Value)
+		},
+	}
+}
+
+type eventMatcher struct {
+	description string
+	matches     func(Event) (bool, error)
+}
+
+func (m *eventMatcher) Match(actual interface{}) (bool, error) {
+	sender, ok := actual.(*FakeSender)
+	if !ok {
+		return false, fmt.Errorf("metric matchers expect a *metrictest.FakeSender, got\n%s", format.Object(actual, 1))
+	}
+
+	for _, event := range sender.Events() {
+		matched, err := m.matches(event)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *eventMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(events(actual), m.description)
+}
+
+func (m *eventMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(events(actual), "not "+m.description)
+}
+
+func events(actual interface{}) interface{} {
+	if sender, ok := actual.(*FakeSender); ok {
+		return sender.Events()
+	}
+	return actual
+}
+
+func matchValue(expected, actual interface{}) (bool, error) {
+	if matcher, ok := expected.(types.GomegaMatcher); ok {
+		return matcher.Match(actual)
+	}
+	return gomega.Equal(expected).Match(actual)
+}
+
+func describe(expected interface{}) string {
+	if _, ok := expected.(types.GomegaMatcher); ok {
+		return "matching the given matcher"
+	}
+	return fmt.Sprintf("%v", expected)
+}
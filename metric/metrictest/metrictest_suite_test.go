@@ -0,0 +1,13 @@
+package metrictest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestMetrictest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metrictest Suite")
+}
@@ -0,0 +1,116 @@
+package metrictest_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric/metrictest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FakeSender", func() {
+	var sender *metrictest.FakeSender
+
+	BeforeEach(func() {
+		sender = metrictest.NewFakeSender()
+	})
+
+	It("records every call in order", func() {
+		metric.Counter("Requests").WithSender(sender).Increment()
+		metric.Counter("Requests").WithTags(map[string]string{"domain": "cf-apps"}).WithSender(sender).Add(3)
+		metric.Duration("Latency").WithSender(sender).Send(time.Second)
+		metric.Mebibytes("Memory").WithSender(sender).Send(256)
+
+		Expect(sender.Events()).To(Equal([]metrictest.Event{
+			{Type: metrictest.IncrementCounterEvent, Name: "Requests", Value: 1},
+			{Type: metrictest.AddToCounterEvent, Name: "Requests", Value: 3, Tags: map[string]string{"domain": "cf-apps"}},
+			{Type: metrictest.SendValueEvent, Name: "Latency", Value: float64(time.Second), Unit: "nanos"},
+			{Type: metrictest.SendValueEvent, Name: "Memory", Value: 256, Unit: "MiB"},
+		}))
+		Expect(sender.CounterTotal("Requests")).To(Equal(uint64(4)))
+	})
+
+	It("only records the metrics bound to it", func() {
+		other := metrictest.NewFakeSender()
+		metric.Counter("Requests").WithSender(other).Increment()
+		Expect(sender.Events()).To(BeEmpty())
+		Expect(other).To(metrictest.HaveIncrementedCounter("Requests"))
+	})
+
+	It("forgets the events on Reset", func() {
+		metric.Counter("Requests").WithSender(sender).Increment()
+		sender.Reset()
+		Expect(sender.Events()).To(BeEmpty())
+	})
+
+	Describe("Install", func() {
+		var restore func()
+
+		BeforeEach(func() {
+			sender, restore = metrictest.Install()
+		})
+
+		AfterEach(func() {
+			restore()
+		})
+
+		It("receives the metrics not bound to a sender", func() {
+			metric.Counter("Requests").Increment()
+			Expect(sender).To(metrictest.HaveIncrementedCounter("Requests"))
+		})
+
+		It("restores the previous sender", func() {
+			restore()
+			metric.Counter("Requests").Increment()
+			Expect(sender.Events()).To(BeEmpty())
+		})
+	})
+
+	Describe("matchers", func() {
+		BeforeEach(func() {
+			metric.Counter("Requests").WithSender(sender).Increment()
+			metric.Counter("Bytes").WithSender(sender).Add(42)
+			metric.Duration("Latency").WithSender(sender).Send(150 * time.Millisecond)
+			metric.Mebibytes("Memory").WithSender(sender).Send(256)
+			metric.Bytes("Payload").WithSender(sender).Send(1536 * 1024)
+			metric.Requests("InFlight").WithSender(sender).Send(7)
+		})
+
+		It("match counters", func() {
+			Expect(sender).To(metrictest.HaveIncrementedCounter("Requests"))
+			Expect(sender).To(metrictest.HaveIncrementedCounter("Bytes"))
+			Expect(sender).NotTo(metrictest.HaveIncrementedCounter("Latency"))
+
+			Expect(sender).To(metrictest.HaveAddedToCounter("Bytes", uint64(42)))
+			Expect(sender).To(metrictest.HaveAddedToCounter("Bytes", BeNumerically(">", 40)))
+			Expect(sender).NotTo(metrictest.HaveAddedToCounter("Bytes", uint64(1)))
+		})
+
+		It("match values", func() {
+			Expect(sender).To(metrictest.HaveSentDuration("Latency"))
+			Expect(sender).To(metrictest.HaveSentDuration("Latency", 150*time.Millisecond))
+			Expect(sender).To(metrictest.HaveSentDuration("Latency", BeNumerically(">", 100*time.Millisecond)))
+			Expect(sender).NotTo(metrictest.HaveSentDuration("Memory"))
+
+			Expect(sender).To(metrictest.HaveSentMebibytes("Memory", 256))
+			Expect(sender).To(metrictest.HaveSentMebibytes("Payload", 1.5))
+			Expect(sender).NotTo(metrictest.HaveSentMebibytes("Payload", 1))
+			Expect(sender).To(metrictest.HaveSentValue("InFlight", 7.0, "Req"))
+			Expect(sender).NotTo(metrictest.HaveSentValue("InFlight", 7.0, "Metric"))
+		})
+
+		It("describe the recorded events on failure", func() {
+			matcher := metrictest.HaveIncrementedCounter("Missing")
+			Expect(matcher.Match(sender)).To(BeFalse())
+			Expect(matcher.FailureMessage(sender)).To(ContainSubstring(`to have incremented counter "Missing"`))
+			Expect(matcher.FailureMessage(sender)).To(ContainSubstring("Requests"))
+		})
+
+		It("errors when given something other than a FakeSender", func() {
+			_, err := metrictest.HaveIncrementedCounter("Requests").Match("not a sender")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,220 @@
+package metric
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	prometheusInvalidNameChars  = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	prometheusInvalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+const (
+	prometheusCounter = "counter"
+	prometheusGauge   = "gauge"
+)
+
+// PrometheusTypeConflictError is returned, and the metric dropped, when a
+// name is sent both as a counter and as a value, including names that only
+// match once sanitised.
+type PrometheusTypeConflictError struct {
+	Name         string
+	Type         string
+	ExistingType string
+}
+
+func (e PrometheusTypeConflictError) Error() string {
+	return fmt.Sprintf("prometheus metric %s is already a %s, cannot send it as a %s", e.Name, e.ExistingType, e.Type)
+}
+
+type prometheusSeries struct {
+	name  string
+	tags  map[string]string
+	value float64
+}
+
+// PrometheusSender serves the metrics it receives in the Prometheus text
+// exposition format. Counters are exposed as counters and every other metric
+// as a gauge holding its last value; metric and label names are kept, with
+// characters Prometheus does not allow replaced by underscores. Series that
+// are identical once sanitised are combined.
+type PrometheusSender struct {
+	// ErrorLog receives the errors from writing responses in ServeHTTP. The
+	// log package's standard logger is used when it is nil.
+	ErrorLog *log.Logger
+
+	lock     sync.Mutex
+	counters map[string]*prometheusSeries
+	gauges   map[string]*prometheusSeries
+	types    map[string]string
+}
+
+func NewPrometheusSender() *PrometheusSender {
+	return &PrometheusSender{
+		counters: map[string]*prometheusSeries{},
+		gauges:   map[string]*prometheusSeries{},
+		types:    map[string]string{},
+	}
+}
+
+func (s *PrometheusSender) IncrementCounter(name string, tags map[string]string) error {
+	return s.AddToCounter(name, 1, tags)
+}
+
+func (s *PrometheusSender) AddToCounter(name string, delta uint64, tags map[string]string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	series, err := s.series(prometheusCounter, name, tags)
+	if err != nil {
+		return err
+	}
+
+	series.value += float64(delta)
+	return nil
+}
+
+func (s *PrometheusSender) SendValue(name string, value float64, unit string, tags map[string]string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	series, err := s.series(prometheusGauge, name, tags)
+	if err != nil {
+		return err
+	}
+
+	series.value = value
+	return nil
+}
+
+func (s *PrometheusSender) series(metricType, name string, tags map[string]string) (*prometheusSeries, error) {
+	name = prometheusName(name)
+	if existing, ok := s.types[name]; ok && existing != metricType {
+		return nil, PrometheusTypeConflictError{Name: name, Type: metricType, ExistingType: existing}
+	}
+	s.types[name] = metricType
+
+	all := s.gauges
+	if metricType == prometheusCounter {
+		all = s.counters
+	}
+
+	labels := prometheusLabelTags(tags)
+	key := seriesKey(name, labels)
+	series, ok := all[key]
+	if !ok {
+		series = &prometheusSeries{name: name, tags: labels}
+		all[key] = series
+	}
+	return series, nil
+}
+
+func (s *PrometheusSender) WriteTo(w io.Writer) (int64, error) {
+	s.lock.Lock()
+	buffer := &bytes.Buffer{}
+	writePrometheusSeries(buffer, prometheusCounter, s.counters)
+	writePrometheusSeries(buffer, prometheusGauge, s.gauges)
+	s.lock.Unlock()
+
+	return buffer.WriteTo(w)
+}
+
+// ServeHTTP renders the metrics before writing any of the response, so that
+// a failure to render is a 500 rather than a truncated body.
+func (s *PrometheusSender) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body := &bytes.Buffer{}
+	_, err := s.WriteTo(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	_, err = body.WriteTo(w)
+	if err != nil {
+		s.logf("metric: failed to write the Prometheus metrics: %s", err)
+	}
+}
+
+func (s *PrometheusSender) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+func writePrometheusSeries(w io.Writer, metricType string, all map[string]*prometheusSeries) {
+	byName := map[string][]*prometheusSeries{}
+	for _, series := range all {
+		byName[series.name] = append(byName[series.name], series)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		lines := []string{}
+		for _, series := range byName[name] {
+			lines = append(lines, name+prometheusLabels(series.tags)+" "+strconv.FormatFloat(series.value, 'g', -1, 64))
+		}
+		sort.Strings(lines)
+
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+func prometheusName(name string) string {
+	name = prometheusInvalidNameChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// prometheusLabelTags sanitises the tag keys into label names. Should two keys
+// sanitise to the same label, the value of the later key in sorted order wins.
+func prometheusLabelTags(tags map[string]string) map[string]string {
+	labels := map[string]string{}
+	for _, key := range sortedTagKeys(tags) {
+		labels[prometheusLabelName(key)] = tags[key]
+	}
+	return labels
+}
+
+func prometheusLabelName(name string) string {
+	name = prometheusInvalidLabelChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+func prometheusLabels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	labels := []string{}
+	for _, key := range sortedTagKeys(tags) {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(tags[key])
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, key, value))
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
@@ -0,0 +1,32 @@
+package metric
+
+// ResultCounter is a pair of counters, <name>Success and <name>Error, that
+// count the outcome of an operation.
+type ResultCounter string
+
+func (name ResultCounter) SuccessCounter() Counter {
+	return Counter(string(name) + "Success")
+}
+
+func (name ResultCounter) ErrorCounter() Counter {
+	return Counter(string(name) + "Error")
+}
+
+func (name ResultCounter) Success() {
+	name.SuccessCounter().Increment()
+}
+
+func (name ResultCounter) Error() {
+	name.ErrorCounter().Increment()
+}
+
+// Record counts err as an error, or a nil err as a success, and returns err
+// so that it can wrap a return statement.
+func (name ResultCounter) Record(err error) error {
+	if err != nil {
+		name.Error()
+	} else {
+		name.Success()
+	}
+	return err
+}
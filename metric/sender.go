@@ -0,0 +1,76 @@
+package metric
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sender is the backend every metric type emits through. Tags may be nil.
+type Sender interface {
+	IncrementCounter(name string, tags map[string]string) error
+	AddToCounter(name string, delta uint64, tags map[string]string) error
+	SendValue(name string, value float64, unit string, tags map[string]string) error
+}
+
+var (
+	senderLock sync.RWMutex
+	sender     Sender = DropsondeSender{}
+)
+
+// SetSender replaces the package-level sender and returns the previous one,
+// so that it can be restored.
+func SetSender(s Sender) Sender {
+	senderLock.Lock()
+	defer senderLock.Unlock()
+
+	previous := sender
+	sender = s
+	return previous
+}
+
+func CurrentSender() Sender {
+	senderLock.RLock()
+	defer senderLock.RUnlock()
+
+	return sender
+}
+
+// senderOrCurrent is the sender a metric is bound to, or the package-level
+// sender for unbound metrics.
+func senderOrCurrent(s Sender) Sender {
+	if s != nil {
+		return s
+	}
+	return CurrentSender()
+}
+
+func incrementCounter(s Sender, name string, tags map[string]string) error {
+	return senderOrCurrent(s).IncrementCounter(name, tags)
+}
+
+func addToCounter(s Sender, name string, delta uint64, tags map[string]string) error {
+	return senderOrCurrent(s).AddToCounter(name, delta, tags)
+}
+
+func sendValue(s Sender, name string, value float64, unit Unit, tags map[string]string) error {
+	return senderOrCurrent(s).SendValue(name, value, string(unit), tags)
+}
+
+func sortedTagKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// seriesKey identifies a metric name together with its tags.
+func seriesKey(name string, tags map[string]string) string {
+	parts := []string{name}
+	for _, key := range sortedTagKeys(tags) {
+		parts = append(parts, key+"="+tags[key])
+	}
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,198 @@
+package metric_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func valueOf(sender *metric.MemorySender, name string, tags map[string]string) metric.MemoryValue {
+	value, found := sender.Value(name, tags)
+	Expect(found).To(BeTrue())
+	return value
+}
+
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingResponseWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+var _ = Describe("Sender", func() {
+	var (
+		sender   *metric.MemorySender
+		previous metric.Sender
+	)
+
+	BeforeEach(func() {
+		sender = metric.NewMemorySender()
+		previous = metric.SetSender(sender)
+	})
+
+	AfterEach(func() {
+		metric.SetSender(previous)
+	})
+
+	It("defaults to dropsonde", func() {
+		Expect(previous).To(Equal(metric.DropsondeSender{}))
+	})
+
+	It("routes every metric type through the package-level sender", func() {
+		metric.Counter("Counter").Increment()
+		metric.Counter("Counter").Add(2)
+		Expect(metric.Duration("Duration").Send(time.Second)).To(Succeed())
+		Expect(metric.Mebibytes("Mebibytes").Send(512)).To(Succeed())
+		Expect(metric.Metric("Metric").Send(3)).To(Succeed())
+		Expect(metric.Requests("Requests").Send(4)).To(Succeed())
+		Expect(metric.BytesPerSecond("BytesPerSecond").Send(5.5)).To(Succeed())
+		Expect(metric.RequestsPerSecond("RequestsPerSecond").Send(6.5)).To(Succeed())
+
+		Expect(sender.Counter("Counter", nil)).To(Equal(uint64(3)))
+		Expect(valueOf(sender, "Duration", nil)).To(Equal(metric.MemoryValue{Value: float64(time.Second), Unit: "nanos"}))
+		Expect(valueOf(sender, "Mebibytes", nil)).To(Equal(metric.MemoryValue{Value: 512, Unit: "MiB"}))
+		Expect(valueOf(sender, "Metric", nil)).To(Equal(metric.MemoryValue{Value: 3, Unit: "Metric"}))
+		Expect(valueOf(sender, "Requests", nil)).To(Equal(metric.MemoryValue{Value: 4, Unit: "Req"}))
+		Expect(valueOf(sender, "BytesPerSecond", nil)).To(Equal(metric.MemoryValue{Value: 5.5, Unit: "B/s"}))
+		Expect(valueOf(sender, "RequestsPerSecond", nil)).To(Equal(metric.MemoryValue{Value: 6.5, Unit: "Req/s"}))
+	})
+
+	It("keeps tagged series apart", func() {
+		apps := metric.Counter("Desires").WithTags(map[string]string{"domain": "cf-apps"})
+		apps.Increment()
+		apps.WithTags(map[string]string{"cell": "cell-1"}).Add(2)
+		metric.Counter("Desires").Increment()
+
+		Expect(sender.Counter("Desires", nil)).To(Equal(uint64(1)))
+		Expect(sender.Counter("Desires", map[string]string{"domain": "cf-apps"})).To(Equal(uint64(1)))
+		Expect(sender.Counter("Desires", map[string]string{"domain": "cf-apps", "cell": "cell-1"})).To(Equal(uint64(2)))
+
+		Expect(metric.Duration("Latency").WithTags(map[string]string{"lifecycle": "docker"}).Send(time.Millisecond)).To(Succeed())
+		Expect(valueOf(sender, "Latency", map[string]string{"lifecycle": "docker"})).To(Equal(metric.MemoryValue{Value: float64(time.Millisecond), Unit: "nanos"}))
+		_, found := sender.Value("Latency", nil)
+		Expect(found).To(BeFalse())
+	})
+
+	Describe("PrometheusSender", func() {
+		It("exposes the metrics in the text exposition format", func() {
+			prometheus := metric.NewPrometheusSender()
+			prometheus.IncrementCounter("Desires", map[string]string{"domain": "cf-apps"})
+			prometheus.AddToCounter("Desires", 2, map[string]string{"domain": "cf-apps"})
+			prometheus.AddToCounter("Desires", 1, map[string]string{"domain": `cf-"tasks"`})
+			prometheus.SendValue("LRPs.Running", 3, "Metric", nil)
+			prometheus.SendValue("LRPs.Running", 4, "Metric", nil)
+
+			recorder := httptest.NewRecorder()
+			prometheus.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+			Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("text/plain"))
+			Expect(recorder.Body.String()).To(Equal(`# TYPE Desires counter
+Desires{domain="cf-\"tasks\""} 1
+Desires{domain="cf-apps"} 3
+# TYPE LRPs_Running gauge
+LRPs_Running 4
+`))
+		})
+
+		It("logs failures to write the response", func() {
+			logs := &bytes.Buffer{}
+			prometheus := metric.NewPrometheusSender()
+			prometheus.ErrorLog = log.New(logs, "", 0)
+			prometheus.IncrementCounter("Desires", nil)
+
+			writer := failingResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+			prometheus.ServeHTTP(writer, httptest.NewRequest("GET", "/metrics", nil))
+
+			Expect(writer.Header().Get("Content-Length")).To(Equal(strconv.Itoa(len("# TYPE Desires counter\nDesires 1\n"))))
+			Expect(logs.String()).To(Equal("metric: failed to write the Prometheus metrics: connection reset\n"))
+		})
+
+		It("rejects a name sent both as a counter and as a value", func() {
+			prometheus := metric.NewPrometheusSender()
+			Expect(prometheus.IncrementCounter("Desires", nil)).To(Succeed())
+
+			err := prometheus.SendValue("Desires", 1, "Metric", nil)
+			Expect(err).To(Equal(metric.PrometheusTypeConflictError{Name: "Desires", Type: "gauge", ExistingType: "counter"}))
+
+			err = prometheus.AddToCounter("LRPs.Running", 1, nil)
+			Expect(err).To(Succeed())
+			Expect(prometheus.SendValue("LRPs:Running", 1, "Metric", nil)).To(Succeed())
+			Expect(prometheus.SendValue("LRPs_Running", 1, "Metric", nil)).To(BeAssignableToTypeOf(metric.PrometheusTypeConflictError{}))
+
+			buffer := &bytes.Buffer{}
+			prometheus.WriteTo(buffer)
+			Expect(buffer.String()).To(Equal(`# TYPE Desires counter
+Desires 1
+# TYPE LRPs_Running counter
+LRPs_Running 1
+# TYPE LRPs:Running gauge
+LRPs:Running 1
+`))
+		})
+
+		It("combines series that are identical once sanitised, with valid label names", func() {
+			prometheus := metric.NewPrometheusSender()
+			prometheus.AddToCounter("LRPs.Desired", 1, map[string]string{"cell:id": "cell-1"})
+			prometheus.AddToCounter("LRPs_Desired", 2, map[string]string{"cell.id": "cell-1"})
+			prometheus.AddToCounter("LRPs_Desired", 4, map[string]string{"1st": "yes"})
+
+			buffer := &bytes.Buffer{}
+			prometheus.WriteTo(buffer)
+			Expect(buffer.String()).To(Equal(`# TYPE LRPs_Desired counter
+LRPs_Desired{_1st="yes"} 4
+LRPs_Desired{cell_id="cell-1"} 3
+`))
+		})
+	})
+
+	Describe("StatsdSender", func() {
+		It("writes statsd lines", func() {
+			buffer := &bytes.Buffer{}
+			statsd := metric.NewStatsdSenderWithWriter(buffer, "diego")
+
+			statsd.IncrementCounter("Desires", map[string]string{"domain": "cf-apps", "cell": "cell-1"})
+			statsd.AddToCounter("Desires", 5, nil)
+			statsd.SendValue("Latency", float64(1500*time.Microsecond), "nanos", nil)
+			statsd.SendValue("Memory", 256, "MiB", nil)
+			statsd.SendValue("Uptime", 2.5, "s", nil)
+
+			Expect(buffer.String()).To(Equal(`diego.Desires:1|c|#cell:cell-1,domain:cf-apps
+diego.Desires:5|c
+diego.Latency:1.5|ms
+diego.Memory:256|g
+diego.Uptime:2500|ms
+`))
+		})
+
+		It("resets a gauge before sending a negative value", func() {
+			buffer := &bytes.Buffer{}
+			statsd := metric.NewStatsdSenderWithWriter(buffer, "")
+
+			statsd.SendValue("Delta", -2, "Metric", map[string]string{"cell": "cell-1"})
+			statsd.SendValue("Latency", -float64(time.Millisecond), "nanos", nil)
+
+			Expect(buffer.String()).To(Equal(`Delta:0|g|#cell:cell-1
+Delta:-2|g|#cell:cell-1
+Latency:-1|ms
+`))
+		})
+
+		It("replaces the statsd separators in names and tags", func() {
+			buffer := &bytes.Buffer{}
+			statsd := metric.NewStatsdSenderWithWriter(buffer, "")
+
+			statsd.IncrementCounter("Desires:total|x", map[string]string{"cell,id": "cell#1", "route": "a.example.com:8080|tcp"})
+
+			Expect(buffer.String()).To(Equal("Desires_total_x:1|c|#cell_id:cell_1,route:a.example.com_8080_tcp\n"))
+		})
+	})
+})
@@ -0,0 +1,91 @@
+package metric
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatsdSender writes every metric as a statsd line. Counters become counts,
+// durations become timings in milliseconds and every other metric becomes a
+// gauge. Tags are written in the DogStatsD "|#key:value" extension. The
+// characters statsd uses as separators are replaced with '_' in names and
+// tags.
+type StatsdSender struct {
+	lock   sync.Mutex
+	writer io.Writer
+	prefix string
+}
+
+// NewStatsdSender sends to the statsd server at address over UDP. A
+// non-empty prefix is joined to every metric name with a dot.
+func NewStatsdSender(address, prefix string) (*StatsdSender, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewStatsdSenderWithWriter(conn, prefix), nil
+}
+
+var statsdSeparators = strings.NewReplacer(":", "_", "|", "_", "#", "_", ",", "_", "\n", "_")
+
+func NewStatsdSenderWithWriter(writer io.Writer, prefix string) *StatsdSender {
+	return &StatsdSender{writer: writer, prefix: prefix}
+}
+
+func (s *StatsdSender) IncrementCounter(name string, tags map[string]string) error {
+	return s.AddToCounter(name, 1, tags)
+}
+
+func (s *StatsdSender) AddToCounter(name string, delta uint64, tags map[string]string) error {
+	return s.write(name, tags, "c", strconv.FormatUint(delta, 10))
+}
+
+func (s *StatsdSender) SendValue(name string, value float64, unit string, tags map[string]string) error {
+	switch Unit(unit) {
+	case UnitNanos:
+		milliseconds := value / float64(time.Millisecond)
+		return s.write(name, tags, "ms", strconv.FormatFloat(milliseconds, 'f', -1, 64))
+	case UnitSeconds:
+		return s.write(name, tags, "ms", strconv.FormatFloat(value*1000, 'f', -1, 64))
+	}
+
+	gauge := strconv.FormatFloat(value, 'f', -1, 64)
+	if value < 0 {
+		// a signed gauge is a change to the stored value, so reset it first
+		return s.write(name, tags, "g", "0", gauge)
+	}
+	return s.write(name, tags, "g", gauge)
+}
+
+// write sends one line per value, all in a single write.
+func (s *StatsdSender) write(name string, tags map[string]string, statsdType string, values ...string) error {
+	if s.prefix != "" {
+		name = s.prefix + "." + name
+	}
+	name = statsdSeparators.Replace(name)
+
+	suffix := ""
+	if len(tags) > 0 {
+		pairs := []string{}
+		for _, key := range sortedTagKeys(tags) {
+			pairs = append(pairs, statsdSeparators.Replace(key)+":"+statsdSeparators.Replace(tags[key]))
+		}
+		suffix = "|#" + strings.Join(pairs, ",")
+	}
+
+	lines := ""
+	for _, value := range values {
+		lines += fmt.Sprintf("%s:%s|%s%s\n", name, value, statsdType, suffix)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, err := io.WriteString(s.writer, lines)
+	return err
+}
@@ -0,0 +1,290 @@
+package metric
+
+import "time"
+
+// Tags dimension a metric, for example by domain, cell or lifecycle.
+type Tags map[string]string
+
+// Merge returns a copy of the tags overlaid with more. Neither is modified.
+func (t Tags) Merge(more map[string]string) Tags {
+	merged := Tags{}
+	for key, value := range t {
+		merged[key] = value
+	}
+	for key, value := range more {
+		merged[key] = value
+	}
+	return merged
+}
+
+// TaggedCounter, like the other tagged metrics, sends through its Sender, or
+// through the package-level sender when that is nil. WithSender binds a
+// metric to a sender, so that each test can hand its own fake to the code
+// under test instead of replacing the package-level sender.
+type TaggedCounter struct {
+	Name   Counter
+	Tags   Tags
+	Sender Sender
+}
+
+func (c Counter) WithTags(tags map[string]string) TaggedCounter {
+	return TaggedCounter{Name: c, Tags: Tags{}.Merge(tags)}
+}
+
+func (c TaggedCounter) WithTags(tags map[string]string) TaggedCounter {
+	return TaggedCounter{Name: c.Name, Tags: c.Tags.Merge(tags), Sender: c.Sender}
+}
+
+func (c Counter) WithSender(sender Sender) TaggedCounter {
+	return TaggedCounter{Name: c, Sender: sender}
+}
+
+func (c TaggedCounter) WithSender(sender Sender) TaggedCounter {
+	return TaggedCounter{Name: c.Name, Tags: c.Tags, Sender: sender}
+}
+
+func (c TaggedCounter) Increment() {
+	incrementCounter(c.Sender, string(c.Name), c.Tags)
+}
+
+func (c TaggedCounter) Add(i uint64) {
+	addToCounter(c.Sender, string(c.Name), i, c.Tags)
+}
+
+type TaggedDuration struct {
+	Name   Duration
+	Tags   Tags
+	Sender Sender
+}
+
+func (name Duration) WithTags(tags map[string]string) TaggedDuration {
+	return TaggedDuration{Name: name, Tags: Tags{}.Merge(tags)}
+}
+
+func (d TaggedDuration) WithTags(tags map[string]string) TaggedDuration {
+	return TaggedDuration{Name: d.Name, Tags: d.Tags.Merge(tags), Sender: d.Sender}
+}
+
+func (name Duration) WithSender(sender Sender) TaggedDuration {
+	return TaggedDuration{Name: name, Sender: sender}
+}
+
+func (d TaggedDuration) WithSender(sender Sender) TaggedDuration {
+	return TaggedDuration{Name: d.Name, Tags: d.Tags, Sender: sender}
+}
+
+func (d TaggedDuration) Send(duration time.Duration) error {
+	return sendValue(d.Sender, string(d.Name), float64(duration), UnitNanos, d.Tags)
+}
+
+type TaggedMebibytes struct {
+	Name   Mebibytes
+	Tags   Tags
+	Sender Sender
+}
+
+func (name Mebibytes) WithTags(tags map[string]string) TaggedMebibytes {
+	return TaggedMebibytes{Name: name, Tags: Tags{}.Merge(tags)}
+}
+
+func (m TaggedMebibytes) WithTags(tags map[string]string) TaggedMebibytes {
+	return TaggedMebibytes{Name: m.Name, Tags: m.Tags.Merge(tags), Sender: m.Sender}
+}
+
+func (name Mebibytes) WithSender(sender Sender) TaggedMebibytes {
+	return TaggedMebibytes{Name: name, Sender: sender}
+}
+
+func (m TaggedMebibytes) WithSender(sender Sender) TaggedMebibytes {
+	return TaggedMebibytes{Name: m.Name, Tags: m.Tags, Sender: sender}
+}
+
+func (m TaggedMebibytes) Send(mebibytes int) error {
+	return sendValue(m.Sender, string(m.Name), float64(mebibytes), UnitMebibytes, m.Tags)
+}
+
+type TaggedMetric struct {
+	Name   Metric
+	Tags   Tags
+	Sender Sender
+}
+
+func (name Metric) WithTags(tags map[string]string) TaggedMetric {
+	return TaggedMetric{Name: name, Tags: Tags{}.Merge(tags)}
+}
+
+func (m TaggedMetric) WithTags(tags map[string]string) TaggedMetric {
+	return TaggedMetric{Name: m.Name, Tags: m.Tags.Merge(tags), Sender: m.Sender}
+}
+
+func (name Metric) WithSender(sender Sender) TaggedMetric {
+	return TaggedMetric{Name: name, Sender: sender}
+}
+
+func (m TaggedMetric) WithSender(sender Sender) TaggedMetric {
+	return TaggedMetric{Name: m.Name, Tags: m.Tags, Sender: sender}
+}
+
+func (m TaggedMetric) Send(value int) error {
+	return sendValue(m.Sender, string(m.Name), float64(value), UnitMetric, m.Tags)
+}
+
+type TaggedRequests struct {
+	Name   Requests
+	Tags   Tags
+	Sender Sender
+}
+
+func (name Requests) WithTags(tags map[string]string) TaggedRequests {
+	return TaggedRequests{Name: name, Tags: Tags{}.Merge(tags)}
+}
+
+func (r TaggedRequests) WithTags(tags map[string]string) TaggedRequests {
+	return TaggedRequests{Name: r.Name, Tags: r.Tags.Merge(tags), Sender: r.Sender}
+}
+
+func (name Requests) WithSender(sender Sender) TaggedRequests {
+	return TaggedRequests{Name: name, Sender: sender}
+}
+
+func (r TaggedRequests) WithSender(sender Sender) TaggedRequests {
+	return TaggedRequests{Name: r.Name, Tags: r.Tags, Sender: sender}
+}
+
+func (r TaggedRequests) Send(value int) error {
+	return sendValue(r.Sender, string(r.Name), float64(value), UnitRequests, r.Tags)
+}
+
+type TaggedBytesPerSecond struct {
+	Name   BytesPerSecond
+	Tags   Tags
+	Sender Sender
+}
+
+func (name BytesPerSecond) WithTags(tags map[string]string) TaggedBytesPerSecond {
+	return TaggedBytesPerSecond{Name: name, Tags: Tags{}.Merge(tags)}
+}
+
+func (b TaggedBytesPerSecond) WithTags(tags map[string]string) TaggedBytesPerSecond {
+	return TaggedBytesPerSecond{Name: b.Name, Tags: b.Tags.Merge(tags), Sender: b.Sender}
+}
+
+func (name BytesPerSecond) WithSender(sender Sender) TaggedBytesPerSecond {
+	return TaggedBytesPerSecond{Name: name, Sender: sender}
+}
+
+func (b TaggedBytesPerSecond) WithSender(sender Sender) TaggedBytesPerSecond {
+	return TaggedBytesPerSecond{Name: b.Name, Tags: b.Tags, Sender: sender}
+}
+
+func (b TaggedBytesPerSecond) Send(value float64) error {
+	return sendValue(b.Sender, string(b.Name), value, UnitBytesPerSecond, b.Tags)
+}
+
+type TaggedRequestsPerSecond struct {
+	Name   RequestsPerSecond
+	Tags   Tags
+	Sender Sender
+}
+
+func (name RequestsPerSecond) WithTags(tags map[string]string) TaggedRequestsPerSecond {
+	return TaggedRequestsPerSecond{Name: name, Tags: Tags{}.Merge(tags)}
+}
+
+func (r TaggedRequestsPerSecond) WithTags(tags map[string]string) TaggedRequestsPerSecond {
+	return TaggedRequestsPerSecond{Name: r.Name, Tags: r.Tags.Merge(tags), Sender: r.Sender}
+}
+
+func (name RequestsPerSecond) WithSender(sender Sender) TaggedRequestsPerSecond {
+	return TaggedRequestsPerSecond{Name: name, Sender: sender}
+}
+
+func (r TaggedRequestsPerSecond) WithSender(sender Sender) TaggedRequestsPerSecond {
+	return TaggedRequestsPerSecond{Name: r.Name, Tags: r.Tags, Sender: sender}
+}
+
+func (r TaggedRequestsPerSecond) Send(value float64) error {
+	return sendValue(r.Sender, string(r.Name), value, UnitRequestsPerSecond, r.Tags)
+}
+
+type TaggedBytes struct {
+	Name   Bytes
+	Tags   Tags
+	Sender Sender
+}
+
+func (name Bytes) WithTags(tags map[string]string) TaggedBytes {
+	return TaggedBytes{Name: name, Tags: Tags{}.Merge(tags)}
+}
+
+func (b TaggedBytes) WithTags(tags map[string]string) TaggedBytes {
+	return TaggedBytes{Name: b.Name, Tags: b.Tags.Merge(tags), Sender: b.Sender}
+}
+
+func (name Bytes) WithSender(sender Sender) TaggedBytes {
+	return TaggedBytes{Name: name, Sender: sender}
+}
+
+func (b TaggedBytes) WithSender(sender Sender) TaggedBytes {
+	return TaggedBytes{Name: b.Name, Tags: b.Tags, Sender: sender}
+}
+
+func (b TaggedBytes) Send(size ByteSize) error {
+	return sendValue(b.Sender, string(b.Name), size.Mebibytes(), UnitMebibytes, b.Tags)
+}
+
+type TaggedSeconds struct {
+	Name   Seconds
+	Tags   Tags
+	Sender Sender
+}
+
+func (name Seconds) WithTags(tags map[string]string) TaggedSeconds {
+	return TaggedSeconds{Name: name, Tags: Tags{}.Merge(tags)}
+}
+
+func (s TaggedSeconds) WithTags(tags map[string]string) TaggedSeconds {
+	return TaggedSeconds{Name: s.Name, Tags: s.Tags.Merge(tags), Sender: s.Sender}
+}
+
+func (name Seconds) WithSender(sender Sender) TaggedSeconds {
+	return TaggedSeconds{Name: name, Sender: sender}
+}
+
+func (s TaggedSeconds) WithSender(sender Sender) TaggedSeconds {
+	return TaggedSeconds{Name: s.Name, Tags: s.Tags, Sender: sender}
+}
+
+func (s TaggedSeconds) Send(duration time.Duration) error {
+	return sendValue(s.Sender, string(s.Name), duration.Seconds(), UnitSeconds, s.Tags)
+}
+
+type TaggedPercentage struct {
+	Name   Percentage
+	Tags   Tags
+	Sender Sender
+}
+
+func (name Percentage) WithTags(tags map[string]string) TaggedPercentage {
+	return TaggedPercentage{Name: name, Tags: Tags{}.Merge(tags)}
+}
+
+func (p TaggedPercentage) WithTags(tags map[string]string) TaggedPercentage {
+	return TaggedPercentage{Name: p.Name, Tags: p.Tags.Merge(tags), Sender: p.Sender}
+}
+
+func (name Percentage) WithSender(sender Sender) TaggedPercentage {
+	return TaggedPercentage{Name: name, Sender: sender}
+}
+
+func (p TaggedPercentage) WithSender(sender Sender) TaggedPercentage {
+	return TaggedPercentage{Name: p.Name, Tags: p.Tags, Sender: sender}
+}
+
+func (p TaggedPercentage) Send(percent float64) error {
+	return sendValue(p.Sender, string(p.Name), percent, UnitPercent, p.Tags)
+}
+
+func (p TaggedPercentage) SendRatio(part, whole float64) error {
+	return p.Send(ratio(part, whole))
+}
@@ -0,0 +1,133 @@
+package metric_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric/metrictest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tags", func() {
+	Describe("Merge", func() {
+		It("overlays the tags on a copy, leaving both unchanged", func() {
+			base := metric.Tags{"domain": "cf-apps", "cell": "cell-1"}
+			more := map[string]string{"cell": "cell-2"}
+
+			merged := base.Merge(more)
+			Expect(merged).To(Equal(metric.Tags{"domain": "cf-apps", "cell": "cell-2"}))
+			Expect(base).To(Equal(metric.Tags{"domain": "cf-apps", "cell": "cell-1"}))
+			Expect(more).To(Equal(map[string]string{"cell": "cell-2"}))
+
+			merged["extra"] = "value"
+			Expect(base).NotTo(HaveKey("extra"))
+		})
+
+		It("merges into nil tags", func() {
+			var tags metric.Tags
+			Expect(tags.Merge(map[string]string{"a": "b"})).To(Equal(metric.Tags{"a": "b"}))
+			Expect(tags.Merge(nil)).To(Equal(metric.Tags{}))
+		})
+	})
+
+	Describe("WithTags", func() {
+		It("does not hold on to the caller's map", func() {
+			tags := map[string]string{"domain": "cf-apps"}
+			counter := metric.Counter("Desires").WithTags(tags)
+
+			tags["domain"] = "cf-tasks"
+			Expect(counter.Tags).To(Equal(metric.Tags{"domain": "cf-apps"}))
+		})
+
+		It("merges chained tags without changing the parent", func() {
+			parent := metric.Duration("Latency").WithTags(map[string]string{"domain": "cf-apps", "cell": "cell-1"})
+			child := parent.WithTags(map[string]string{"cell": "cell-2"})
+
+			Expect(child.Name).To(Equal(metric.Duration("Latency")))
+			Expect(child.Tags).To(Equal(metric.Tags{"domain": "cf-apps", "cell": "cell-2"}))
+			Expect(parent.Tags).To(Equal(metric.Tags{"domain": "cf-apps", "cell": "cell-1"}))
+		})
+	})
+
+	Describe("emission", func() {
+		var (
+			sender  *metrictest.FakeSender
+			restore func()
+		)
+
+		BeforeEach(func() {
+			sender, restore = metrictest.Install()
+		})
+
+		AfterEach(func() {
+			restore()
+		})
+
+		It("sends the untagged types without tags", func() {
+			metric.Counter("Counter").Increment()
+			metric.Counter("Counter").Add(2)
+			metric.Duration("Duration").Send(time.Second)
+			metric.Mebibytes("Mebibytes").Send(1)
+			metric.Metric("Metric").Send(1)
+			metric.Requests("Requests").Send(1)
+			metric.BytesPerSecond("BytesPerSecond").Send(1)
+			metric.RequestsPerSecond("RequestsPerSecond").Send(1)
+
+			events := sender.Events()
+			Expect(events).To(HaveLen(8))
+			for _, event := range events {
+				Expect(event.Tags).To(BeNil(), event.Name)
+			}
+		})
+
+		It("sends each tagged type with its tags and unit", func() {
+			tags := map[string]string{"domain": "cf-apps"}
+			bound := metrictest.NewFakeSender()
+
+			metric.Counter("Counter").WithTags(tags).WithSender(bound).Increment()
+			metric.Counter("Counter").WithTags(tags).WithSender(bound).Add(2)
+			metric.Duration("Duration").WithTags(tags).WithSender(bound).Send(time.Second)
+			metric.Mebibytes("Mebibytes").WithTags(tags).WithSender(bound).Send(1)
+			metric.Metric("Metric").WithTags(tags).WithSender(bound).Send(1)
+			metric.Requests("Requests").WithTags(tags).WithSender(bound).Send(1)
+			metric.BytesPerSecond("BytesPerSecond").WithTags(tags).WithSender(bound).Send(1)
+			metric.RequestsPerSecond("RequestsPerSecond").WithTags(tags).WithSender(bound).Send(1)
+
+			Expect(sender.Events()).To(BeEmpty())
+			Expect(bound.Events()).To(Equal([]metrictest.Event{
+				{Type: metrictest.IncrementCounterEvent, Name: "Counter", Value: 1, Tags: tags},
+				{Type: metrictest.AddToCounterEvent, Name: "Counter", Value: 2, Tags: tags},
+				{Type: metrictest.SendValueEvent, Name: "Duration", Value: float64(time.Second), Unit: "nanos", Tags: tags},
+				{Type: metrictest.SendValueEvent, Name: "Mebibytes", Value: 1, Unit: "MiB", Tags: tags},
+				{Type: metrictest.SendValueEvent, Name: "Metric", Value: 1, Unit: "Metric", Tags: tags},
+				{Type: metrictest.SendValueEvent, Name: "Requests", Value: 1, Unit: "Req", Tags: tags},
+				{Type: metrictest.SendValueEvent, Name: "BytesPerSecond", Value: 1, Unit: "B/s", Tags: tags},
+				{Type: metrictest.SendValueEvent, Name: "RequestsPerSecond", Value: 1, Unit: "Req/s", Tags: tags},
+			}))
+		})
+
+		It("keeps the bound sender when adding tags", func() {
+			bound := metrictest.NewFakeSender()
+			tags := map[string]string{"domain": "cf-apps"}
+
+			metric.Counter("Counter").WithSender(bound).WithTags(tags).Increment()
+			metric.NewGauge("Gauge").WithSender(bound).WithTags(tags).Set(2)
+			metric.Value{Name: "Value", Unit: metric.UnitMetric}.WithSender(bound).WithTags(tags).Send(3)
+
+			histogram := metric.NewHistogram("Histogram", metric.UnitMetric, []float64{10}).WithSender(bound).WithTags(tags)
+			histogram.Observe(4)
+			Expect(histogram.Emit()).To(Succeed())
+
+			Expect(sender.Events()).To(BeEmpty())
+			Expect(bound).To(metrictest.HaveIncrementedCounter("Counter"))
+			Expect(bound).To(metrictest.HaveSentValue("Gauge", 2.0, "Metric"))
+			Expect(bound).To(metrictest.HaveSentValue("Value", 3.0, "Metric"))
+			Expect(bound).To(metrictest.HaveSentValue("Histogram.count", 1.0, "Metric"))
+			for _, event := range bound.Events() {
+				Expect(event.Tags).To(Equal(tags), event.Name)
+			}
+		})
+	})
+})
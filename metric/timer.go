@@ -0,0 +1,21 @@
+package metric
+
+import "time"
+
+// StartTimer returns a function that sends the time elapsed since StartTimer
+// was called, for use as
+//
+//	defer metric.Duration("RequestLatency").StartTimer()()
+func (name Duration) StartTimer() func() error {
+	start := time.Now()
+	return func() error {
+		return name.Send(time.Since(start))
+	}
+}
+
+func (d TaggedDuration) StartTimer() func() error {
+	start := time.Now()
+	return func() error {
+		return d.Send(time.Since(start))
+	}
+}
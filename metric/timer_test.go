@@ -0,0 +1,59 @@
+package metric_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timer", func() {
+	var (
+		sender   *metric.MemorySender
+		previous metric.Sender
+	)
+
+	BeforeEach(func() {
+		sender = metric.NewMemorySender()
+		previous = metric.SetSender(sender)
+	})
+
+	AfterEach(func() {
+		metric.SetSender(previous)
+	})
+
+	It("sends the elapsed time when stopped", func() {
+		stop := metric.Duration("ConvergenceDuration").StartTimer()
+		time.Sleep(time.Millisecond)
+		Expect(stop()).To(Succeed())
+
+		value := valueOf(sender, "ConvergenceDuration", nil)
+		Expect(value.Unit).To(Equal("nanos"))
+		Expect(value.Value).To(BeNumerically(">=", float64(time.Millisecond)))
+	})
+
+	It("times tagged durations", func() {
+		stop := metric.Duration("ConvergenceDuration").WithTags(map[string]string{"domain": "cf-apps"}).StartTimer()
+		Expect(stop()).To(Succeed())
+
+		_, found := sender.Value("ConvergenceDuration", map[string]string{"domain": "cf-apps"})
+		Expect(found).To(BeTrue())
+	})
+
+	Describe("ResultCounter", func() {
+		It("counts successes and errors", func() {
+			counter := metric.ResultCounter("Staging")
+
+			Expect(counter.Record(nil)).To(Succeed())
+			Expect(counter.Record(nil)).To(Succeed())
+			err := errors.New("boom")
+			Expect(counter.Record(err)).To(Equal(err))
+
+			Expect(sender.Counter("StagingSuccess", nil)).To(Equal(uint64(2)))
+			Expect(sender.Counter("StagingError", nil)).To(Equal(uint64(1)))
+		})
+	})
+})
@@ -0,0 +1,93 @@
+package metric
+
+import "time"
+
+// Unit is sent alongside every value so that consumers can tell nanoseconds
+// from seconds or mebibytes from requests.
+type Unit string
+
+const (
+	UnitNanos             Unit = "nanos"
+	UnitSeconds           Unit = "s"
+	UnitBytes             Unit = "B"
+	UnitMebibytes         Unit = "MiB"
+	UnitPercent           Unit = "%"
+	UnitRequests          Unit = "Req"
+	UnitBytesPerSecond    Unit = "B/s"
+	UnitRequestsPerSecond Unit = "Req/s"
+	UnitMetric            Unit = "Metric"
+)
+
+// ByteSize is a number of bytes.
+type ByteSize uint64
+
+const (
+	Byte     ByteSize = 1
+	Kibibyte          = 1024 * Byte
+	Mebibyte          = 1024 * Kibibyte
+	Gibibyte          = 1024 * Mebibyte
+)
+
+func (b ByteSize) Mebibytes() float64 {
+	return float64(b) / float64(Mebibyte)
+}
+
+// Bytes sends a ByteSize in MiB, without rounding.
+type Bytes string
+
+func (name Bytes) Send(size ByteSize) error {
+	return sendValue(nil, string(name), size.Mebibytes(), UnitMebibytes, nil)
+}
+
+// Seconds sends a duration in seconds, for durations too long to be read
+// comfortably in nanoseconds.
+type Seconds string
+
+func (name Seconds) Send(duration time.Duration) error {
+	return sendValue(nil, string(name), duration.Seconds(), UnitSeconds, nil)
+}
+
+// Percentage sends a value where 100 is the whole. It is not clamped, as for
+// example the CPU usage of a container can exceed 100.
+type Percentage string
+
+func (name Percentage) Send(percent float64) error {
+	return sendValue(nil, string(name), percent, UnitPercent, nil)
+}
+
+// SendRatio sends part as a percentage of whole, or 0 when whole is 0.
+func (name Percentage) SendRatio(part, whole float64) error {
+	return name.Send(ratio(part, whole))
+}
+
+// Value is a metric with an explicit unit, for units without a dedicated
+// type.
+type Value struct {
+	Name   string
+	Unit   Unit
+	Tags   Tags
+	Sender Sender
+}
+
+func NewValue(name string, unit Unit) Value {
+	return Value{Name: name, Unit: unit}
+}
+
+func (v Value) WithTags(tags map[string]string) Value {
+	return Value{Name: v.Name, Unit: v.Unit, Tags: v.Tags.Merge(tags), Sender: v.Sender}
+}
+
+func (v Value) WithSender(sender Sender) Value {
+	return Value{Name: v.Name, Unit: v.Unit, Tags: v.Tags, Sender: sender}
+}
+
+func (v Value) Send(value float64) error {
+	return sendValue(v.Sender, v.Name, value, v.Unit, v.Tags)
+}
+
+func ratio(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return part / whole * 100
+}
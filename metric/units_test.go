@@ -0,0 +1,70 @@
+package metric_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Units", func() {
+	var (
+		sender   *metric.MemorySender
+		previous metric.Sender
+	)
+
+	BeforeEach(func() {
+		sender = metric.NewMemorySender()
+		previous = metric.SetSender(sender)
+	})
+
+	AfterEach(func() {
+		metric.SetSender(previous)
+	})
+
+	It("converts byte sizes to MiB", func() {
+		Expect(metric.ByteSize(1536 * metric.Kibibyte).Mebibytes()).To(Equal(1.5))
+		Expect(metric.Gibibyte.Mebibytes()).To(Equal(1024.0))
+
+		Expect(metric.Bytes("Memory").Send(512 * metric.Kibibyte)).To(Succeed())
+		Expect(valueOf(sender, "Memory", nil)).To(Equal(metric.MemoryValue{Value: 0.5, Unit: "MiB"}))
+	})
+
+	It("sends seconds", func() {
+		Expect(metric.Seconds("Uptime").Send(90 * time.Second)).To(Succeed())
+		Expect(valueOf(sender, "Uptime", nil)).To(Equal(metric.MemoryValue{Value: 90, Unit: "s"}))
+	})
+
+	It("sends percentages", func() {
+		Expect(metric.Percentage("CPU").Send(150)).To(Succeed())
+		Expect(valueOf(sender, "CPU", nil)).To(Equal(metric.MemoryValue{Value: 150, Unit: "%"}))
+
+		Expect(metric.Percentage("Used").SendRatio(1, 4)).To(Succeed())
+		Expect(valueOf(sender, "Used", nil).Value).To(Equal(25.0))
+
+		Expect(metric.Percentage("Used").SendRatio(1, 0)).To(Succeed())
+		Expect(valueOf(sender, "Used", nil).Value).To(Equal(0.0))
+	})
+
+	It("sends values with an explicit unit", func() {
+		value := metric.NewValue("Temperature", metric.Unit("C"))
+		Expect(value.Send(21.5)).To(Succeed())
+		Expect(valueOf(sender, "Temperature", nil)).To(Equal(metric.MemoryValue{Value: 21.5, Unit: "C"}))
+	})
+
+	It("tags the unit-safe types", func() {
+		tags := map[string]string{"cell": "cell-1"}
+
+		Expect(metric.Bytes("Memory").WithTags(tags).Send(metric.Mebibyte)).To(Succeed())
+		Expect(metric.Seconds("Uptime").WithTags(tags).Send(time.Second)).To(Succeed())
+		Expect(metric.Percentage("CPU").WithTags(tags).SendRatio(1, 2)).To(Succeed())
+		Expect(metric.NewValue("Temperature", "C").WithTags(tags).Send(20)).To(Succeed())
+
+		Expect(valueOf(sender, "Memory", tags)).To(Equal(metric.MemoryValue{Value: 1, Unit: "MiB"}))
+		Expect(valueOf(sender, "Uptime", tags)).To(Equal(metric.MemoryValue{Value: 1, Unit: "s"}))
+		Expect(valueOf(sender, "CPU", tags)).To(Equal(metric.MemoryValue{Value: 50, Unit: "%"}))
+		Expect(valueOf(sender, "Temperature", tags)).To(Equal(metric.MemoryValue{Value: 20, Unit: "C"}))
+	})
+})